@@ -22,26 +22,36 @@ func NewAthleteClient(url string) *AthleteClient {
 func (c *AthleteClient) GetAthletesByMeeting(meeting string) ([]model.Athlete, error) {
 	fmt.Printf("request '%s'\n", c.apiUrl+"athlete/meet/"+meeting)
 
-	res, err := client.Get(c.apiUrl, "athlete/meet/"+meeting, nil, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
+	var athletes []model.Athlete
+	params := map[string]string{}
+	for {
+		res, err := client.Get(c.apiUrl, "athlete/meet/"+meeting, params, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	if res.StatusCode != http.StatusOK {
-		if res.StatusCode == http.StatusNotFound {
-			return nil, nil
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			if res.StatusCode == http.StatusNotFound {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("GetAthletesByMeeting received error: %d\n", res.StatusCode)
 		}
-		return nil, fmt.Errorf("GetAthletesByMeeting received error: %d\n", res.StatusCode)
-	}
 
-	var athletes []model.Athlete
-	err = json.NewDecoder(res.Body).Decode(&athletes)
-	if err != nil {
-		return nil, err
-	}
+		var page []model.Athlete
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		athletes = append(athletes, page...)
 
-	return athletes, nil
+		// the athletes are returned in pages, the Link header points to the next one
+		params["cursor"] = nextCursor(res)
+		if params["cursor"] == "" {
+			return athletes, nil
+		}
+	}
 }
 
 func (c *AthleteClient) GetAthleteByNameAndYear(name string, year int) (*model.Athlete, bool, error) {
@@ -123,3 +133,49 @@ func (c *AthleteClient) ImportCertificate(name string, athleteId primitive.Objec
 	}
 	return newCertificate, res.StatusCode == http.StatusCreated, nil
 }
+
+func (c *AthleteClient) ImportAthletes(requests []dto.ImportAthleteRequestDto) ([]dto.ImportAthleteResultDto, error) {
+	res, err := client.Post(c.apiUrl, "athlete/import/bulk", requests, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bulk import request returned: %d", res.StatusCode)
+	}
+
+	var results []dto.ImportAthleteResultDto
+	err = json.NewDecoder(res.Body).Decode(&results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetAthletesByIds looks up the athletes with the given ids and DSV ids at once, see dto.AthleteBatchDto.
+func (c *AthleteClient) GetAthletesByIds(ids []primitive.ObjectID, dsvIds []int) (*dto.AthleteBatchDto, error) {
+	request := dto.BatchLookupRequestDto{
+		Ids:    ids,
+		DsvIds: dsvIds,
+	}
+
+	res, err := client.Post(c.apiUrl, "athlete/batch", request, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GetAthletesByIds received error: %d\n", res.StatusCode)
+	}
+
+	result := &dto.AthleteBatchDto{}
+	err = json.NewDecoder(res.Body).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -1 +1,25 @@
 package client
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// nextCursor returns the cursor of the next page linked in the Link header of a list response, it is empty on the last
+// page.
+func nextCursor(res *http.Response) string {
+	for _, link := range strings.Split(res.Header.Get("Link"), ",") {
+		target, params, found := strings.Cut(link, ";")
+		if !found || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+
+		next, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return ""
+		}
+		return next.Query().Get("cursor")
+	}
+	return ""
+}
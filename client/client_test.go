@@ -0,0 +1,33 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNextCursor(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"no link", "", ""},
+		{"last page", `</athlete?limit=20>; rel="first"`, ""},
+		{"next page", `</athlete?limit=20>; rel="first", </athlete?cursor=FwAAAAR2AA&limit=20>; rel="next"`, "FwAAAAR2AA"},
+		{"next page first", `</team?cursor=abc_-1>; rel="next", </team>; rel="first"`, "abc_-1"},
+		{"escaped cursor", `</athlete?cursor=a%2Bb>; rel="next"`, "a+b"},
+		{"invalid url", `<%zz>; rel="next"`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if test.link != "" {
+				res.Header.Set("Link", test.link)
+			}
+			if got := nextCursor(res); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
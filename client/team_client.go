@@ -6,6 +6,7 @@ import (
 	"github.com/swimresults/athlete-service/dto"
 	"github.com/swimresults/athlete-service/model"
 	"github.com/swimresults/service-core/client"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
 )
 
@@ -41,6 +42,26 @@ func (c *TeamClient) ImportTeam(team model.Team, meeting string) (*model.Team, b
 	return newTeam, res.StatusCode == http.StatusCreated, nil
 }
 
+func (c *TeamClient) ImportTeams(requests []dto.ImportTeamRequestDto) ([]dto.ImportTeamResultDto, error) {
+	res, err := client.Post(c.apiUrl, "team/import/bulk", requests, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bulk import request returned: %d", res.StatusCode)
+	}
+
+	var results []dto.ImportTeamResultDto
+	err = json.NewDecoder(res.Body).Decode(&results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
 func (c *TeamClient) GetTeamByName(name string) (*model.Team, bool, error) {
 	fmt.Printf("request '%s'\n", c.apiUrl+"team/name?name="+name)
 
@@ -73,24 +94,60 @@ func (c *TeamClient) GetTeamByName(name string) (*model.Team, bool, error) {
 func (c *TeamClient) GetTeamsByMeeting(meeting string) (*[]model.Team, bool, error) {
 	fmt.Printf("request '%s'\n", c.apiUrl+"team/meet/"+meeting)
 
-	res, err := client.Get(c.apiUrl, "team/meet/"+meeting, nil, nil)
+	teams := &[]model.Team{}
+	params := map[string]string{}
+	for {
+		res, err := client.Get(c.apiUrl, "team/meet/"+meeting, params, nil)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			if res.StatusCode == http.StatusNotFound {
+				return nil, false, nil
+			}
+			return nil, false, fmt.Errorf("GetTeamsByMeeting received error: %d\n", res.StatusCode)
+		}
+
+		var page []model.Team
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, false, err
+		}
+		*teams = append(*teams, page...)
+
+		// the teams are returned in pages, the Link header points to the next one
+		params["cursor"] = nextCursor(res)
+		if params["cursor"] == "" {
+			return teams, true, nil
+		}
+	}
+}
+
+// GetTeamsByIds looks up the teams with the given ids and DSV ids at once, see dto.TeamBatchDto.
+func (c *TeamClient) GetTeamsByIds(ids []primitive.ObjectID, dsvIds []int) (*dto.TeamBatchDto, error) {
+	request := dto.BatchLookupRequestDto{
+		Ids:    ids,
+		DsvIds: dsvIds,
+	}
+
+	res, err := client.Post(c.apiUrl, "team/batch", request, nil)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		if res.StatusCode == http.StatusNotFound {
-			return nil, false, nil
-		}
-		return nil, false, fmt.Errorf("GetTeamsByMeeting received error: %d\n", res.StatusCode)
+		return nil, fmt.Errorf("GetTeamsByIds received error: %d\n", res.StatusCode)
 	}
 
-	teams := &[]model.Team{}
-	err = json.NewDecoder(res.Body).Decode(teams)
+	result := &dto.TeamBatchDto{}
+	err = json.NewDecoder(res.Body).Decode(result)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	return teams, true, nil
+	return result, nil
 }
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/swimresults/athlete-service/dto"
 	"github.com/swimresults/athlete-service/model"
@@ -8,297 +10,574 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 func athleteController() {
-	router.GET("/athlete", getAthletes)
+	router.GET("/athlete", conditionalGet(""), getAthletes)
 
 	router.GET("/athlete/amount", getAthletesAmount)
 	router.GET("/athlete/meet/:meet_id/amount", getAthletesAmountByMeeting)
 
-	router.GET("/athlete/:id", getAthlete)
+	router.GET("/athlete/:id", conditionalGet(""), getAthlete)
 	router.GET("/athlete/name_year", getAthleteByNameAndYear)
 	router.GET("/athlete/alias_year", getAthleteByAliasAndYear)
-	router.GET("/athlete/meet/:meet_id", getAthletesByMeeting)
-	router.GET("/athlete/team/:team_id", getAthletesByTeam)
-	router.GET("/athlete/team/:team_id/meet/:meet_id", getAthletesByTeamAndMeeting)
+	router.GET("/athlete/meet/:meet_id", conditionalGet("meet_id"), getAthletesByMeeting)
+	router.GET("/athlete/team/:team_id", conditionalGet(""), getAthletesByTeam)
+	router.GET("/athlete/team/:team_id/meet/:meet_id", conditionalGet("meet_id"), getAthletesByTeamAndMeeting)
 
 	router.DELETE("/athlete/:id", removeAthlete)
-	router.POST("/athlete", addAthlete)
-	router.POST("/athlete/import", importAthlete)
+	router.POST("/athlete", idempotent(), addAthlete)
+	router.POST("/athlete/import", idempotent(), importAthlete)
+	router.POST("/athlete/import/bulk", idempotent(), importAthletes)
 	router.POST("/athlete/participation", addParticipation)
 	router.PUT("/athlete", updateAthlete)
 
 	router.POST("/athlete/meet/:meet_id/id_list", getAthletesByMeetingAndIdList)
+	router.POST("/athlete/batch", getAthletesByBatch)
 
-	router.HEAD("/athlete", getAthletes)
-	router.HEAD("/athlete/:id", getAthlete)
+	router.GET("/athlete/:id/profile", conditionalGet(""), getAthleteProfile)
+	router.GET("/athlete/:id/provenance", getAthleteProvenance)
+	router.GET("/athlete/:id/conflict", getAthleteConflicts)
+	router.POST("/athlete/:id/lock", lockAthleteFields)
+	router.POST("/athlete/:id/unlock", unlockAthleteFields)
+	router.POST("/athlete/:id/revert", revertAthlete)
+
+	router.HEAD("/athlete", conditionalGet(""), headList)
+	router.HEAD("/athlete/:id", conditionalGet(""), headAthlete)
 }
 
 func getAthletes(c *gin.Context) {
-	athletes, err := service.GetAthletes(extractPagingParams(c))
+	filter, err := extractAthleteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	athletes, page, err := service.GetAthletes(filter, extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athletes)
+	respondList(c, page, athletes)
+}
+
+// extractAthleteFilter reads the structured filters of athlete lists from the query parameters.
+func extractAthleteFilter(c *gin.Context) (service.AthleteFilter, error) {
+	filter := service.AthleteFilter{
+		Gender:       c.Query("gender"),
+		TeamCountry:  c.Query("team_country"),
+		FirstMeeting: c.Query("first_meeting"),
+	}
+
+	numbers := map[string]*int{"year_min": &filter.YearMin, "year_max": &filter.YearMax, "state_id": &filter.StateId}
+	for param, value := range numbers {
+		if c.Query(param) == "" {
+			continue
+		}
+		number, err := strconv.Atoi(c.Query(param))
+		if err != nil {
+			return service.AthleteFilter{}, fmt.Errorf("given %s was not a number", param)
+		}
+		*value = number
+	}
+
+	if c.Query("has_dsv_id") != "" {
+		hasDsvId, err := strconv.ParseBool(c.Query("has_dsv_id"))
+		if err != nil {
+			return service.AthleteFilter{}, fmt.Errorf("given has_dsv_id was not a boolean")
+		}
+		filter.HasDsvId = &hasDsvId
+	}
+	return filter, nil
 }
 
 func getAthletesAmount(c *gin.Context) {
 	starts, err := service.GetAthletesAmount()
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, starts)
+	c.JSON(http.StatusOK, starts)
 }
 
 func getAthletesAmountByMeeting(c *gin.Context) {
 	meeting := c.Param("meet_id")
 	if meeting == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
 		return
 	}
 
 	starts, err := service.GetAthletesAmountByMeeting(meeting)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, starts)
+	c.JSON(http.StatusOK, starts)
 }
 
 func getAthletesByMeeting(c *gin.Context) {
 	id := c.Param("meet_id")
 	if id == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
 		return
 	}
 
-	athletes, err := service.GetAthletesByMeetingId(id, extractPagingParams(c))
+	filter, err := extractAthleteFilter(c)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athletes)
+	athletes, page, err := service.GetAthletesByMeetingId(id, filter, extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, athletes)
 }
 
 func getAthletesByMeetingAndIdList(c *gin.Context) {
 	id := c.Param("meet_id")
 	if id == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
 		return
 	}
 
 	var data dto.AthleteIdList
 	if err := c.BindJSON(&data); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	athletes, err := service.GetAthletesByMeetingAndIdList(id, data.Athletes, extractPagingParams(c))
+	athletes, page, err := service.GetAthletesByMeetingAndIdList(id, data.Athletes, extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athletes)
+	respondList(c, page, athletes)
 }
 
 func getAthletesByTeam(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("team_id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given team_id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given team_id was not of type ObjectID"})
+		return
+	}
+
+	filter, err := extractAthleteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	athletes, err := service.GetAthletesByTeamId(id, extractPagingParams(c))
+	athletes, page, err := service.GetAthletesByTeamId(id, filter, extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athletes)
+	respondList(c, page, athletes)
 }
 
 func getAthletesByTeamAndMeeting(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("team_id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given team_id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given team_id was not of type ObjectID"})
 		return
 	}
 
 	meeting := c.Param("meet_id")
 	if meeting == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		return
+	}
+
+	filter, err := extractAthleteFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	athletes, err := service.GetAthletesByTeamAndMeeting(id, meeting, extractPagingParams(c))
+	athletes, page, err := service.GetAthletesByTeamAndMeeting(id, meeting, filter, extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athletes)
+	respondList(c, page, athletes)
 }
 
 func getAthlete(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
 		return
 	}
 
-	athlete, err := service.GetAthleteById(id)
+	athlete, err := service.GetAthleteWithFieldsById(id, service.ParseFields(c.Query("fields")))
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		c.JSON(readErrorStatus(err, http.StatusNotFound), gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athlete)
+	c.JSON(http.StatusOK, athlete)
+}
+
+// headAthlete answers HEAD requests of an athlete with its existence and the headers set by conditionalGet.
+func headAthlete(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	exists, err := service.AthleteExists(id)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	c.Status(http.StatusOK)
 }
 
 func getAthleteByNameAndYear(c *gin.Context) {
 	name := c.Query("name")
 	if name == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given name was empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given name was empty"})
 		return
 	}
 	year, err := strconv.Atoi(c.Query("year"))
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
 	athlete, err := service.GetAthleteByNameAndYear(name, year)
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athlete)
+	c.JSON(http.StatusOK, athlete)
 }
 
 func getAthleteByAliasAndYear(c *gin.Context) {
 	name := c.Query("alias")
 	if name == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given alias was empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given alias was empty"})
 		return
 	}
 	year, err := strconv.Atoi(c.Query("year"))
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
 	athlete, err := service.GetAthleteByAliasAndYear(name, year)
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, athlete)
+	c.JSON(http.StatusOK, athlete)
 }
 
 func removeAthlete(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
 		return
 	}
 
-	err := service.RemoveAthleteById(id)
+	err := service.RemoveAthleteById(manualContext(c), id)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusNoContent, "")
+	c.JSON(http.StatusNoContent, "")
 }
 
 func addAthlete(c *gin.Context) {
 	var athlete model.Athlete
 	if err := c.BindJSON(&athlete); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	r, err := service.AddAthlete(athlete)
+	r, err := service.AddAthlete(manualContext(c), athlete)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusCreated, r)
+	c.JSON(http.StatusCreated, r)
 }
 
 func addParticipation(c *gin.Context) {
 
 	var data dto.AddParticipationRequestDto
 	if err := c.BindJSON(&data); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
 	if data.MeetingId == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meeting is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meeting is empty"})
 		return
 	}
 
 	if data.AthleteId.IsZero() {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given athlete is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given athlete is empty"})
 		return
 	}
 
-	r, err := service.AddParticipation(data.AthleteId, data.MeetingId)
+	r, err := service.AddParticipation(manualContext(c), data.AthleteId, data.MeetingId)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, r)
+	c.JSON(http.StatusOK, r)
 
 }
 
 func updateAthlete(c *gin.Context) {
 	var athlete model.Athlete
 	if err := c.BindJSON(&athlete); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	r, err := service.UpdateAthlete(athlete)
+	r, err := service.UpdateAthlete(manualContext(c), athlete)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, r)
+	c.JSON(http.StatusOK, r)
 }
 
 func importAthlete(c *gin.Context) {
 	var athlete *model.Athlete
 	var request dto.ImportAthleteRequestDto
 	if err := c.BindJSON(&request); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	athlete, r, err := service.ImportAthlete(request.Athlete, request.Meeting)
+	if isDryRun(c) {
+		plan, err := service.PlanAthleteImport(request.Athlete, request.Meeting)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	ctx, err := importContext(c, request.Meeting, false)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if request.Source != "" {
+		ctx = service.WithProvenanceSource(ctx, request.Source)
+	}
+
+	athlete, r, err := service.ImportAthlete(ctx, request.Athlete, request.Meeting)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		println(err.Error())
 		return
 	}
 
 	if r {
-		c.IndentedJSON(http.StatusCreated, *athlete)
+		c.JSON(http.StatusCreated, *athlete)
 	} else {
-		c.IndentedJSON(http.StatusOK, *athlete)
+		c.JSON(http.StatusOK, *athlete)
+	}
+}
+
+func importAthletes(c *gin.Context) {
+	requests, err := bindJsonOrNdjson[dto.ImportAthleteRequestDto](c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if (isDryRun(c) && !isAsync(c)) || len(requests) == 0 {
+		c.JSON(http.StatusOK, service.ImportAthletes(c.Request.Context(), requests, true))
+		return
+	}
+
+	var ctx context.Context = c.Request.Context()
+	if !isDryRun(c) {
+		ctx, err = importContext(c, requests[0].Meeting, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	if isAsync(c) {
+		job, err := service.AddAthleteImportJob(ctx, requests, isDryRun(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ImportAthletes(ctx, requests, false))
+}
+
+func getAthleteProvenance(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	provenance, err := service.GetProvenance("athlete", id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, provenance)
+}
+
+func getAthleteConflicts(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
 	}
+
+	conflicts, page, err := service.GetImportConflicts("athlete", id, c.Query("meeting"), extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, conflicts)
+}
+
+func lockAthleteFields(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	var request dto.FieldLockRequestDto
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	r, err := service.LockAthleteFields(manualContext(c), id, request.Fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func unlockAthleteFields(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	var request dto.FieldLockRequestDto
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	r, err := service.UnlockAthleteFields(manualContext(c), id, request.Fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func revertAthlete(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	version, convErr := primitive.ObjectIDFromHex(c.Query("version"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given version was not of type ObjectID"})
+		return
+	}
+
+	r, err := service.RevertAthlete(manualContext(c), id, version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func getAthletesByBatch(c *gin.Context) {
+	var request dto.BatchLookupRequestDto
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	result, err := service.GetAthletesByBatch(request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func getAthleteProfile(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	var include []string
+	for _, part := range strings.Split(c.Query("include"), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			include = append(include, part)
+		}
+	}
+
+	profile, err := service.GetAthleteProfile(id, include)
+	if err != nil {
+		c.JSON(readErrorStatus(err, http.StatusNotFound), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
 }
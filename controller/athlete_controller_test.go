@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"github.com/swimresults/athlete-service/service"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExtractAthleteFilter(t *testing.T) {
+	yes := true
+
+	tests := []struct {
+		query   string
+		want    service.AthleteFilter
+		wantErr bool
+	}{
+		{"", service.AthleteFilter{}, false},
+		{"gender=W&team_country=GER&first_meeting=IESC13", service.AthleteFilter{Gender: "W", TeamCountry: "GER", FirstMeeting: "IESC13"}, false},
+		{"year_min=2008&year_max=2010&state_id=3", service.AthleteFilter{YearMin: 2008, YearMax: 2010, StateId: 3}, false},
+		{"has_dsv_id=true", service.AthleteFilter{HasDsvId: &yes}, false},
+		{"year_min=old", service.AthleteFilter{}, true},
+		{"state_id=3.5", service.AthleteFilter{}, true},
+		{"has_dsv_id=maybe", service.AthleteFilter{}, true},
+	}
+
+	for _, test := range tests {
+		c, _ := testContext(http.MethodGet, "/athlete?"+test.query, nil, "")
+		got, err := extractAthleteFilter(c)
+		if (err != nil) != test.wantErr {
+			t.Errorf("extractAthleteFilter(%q): got error %v, want error %t", test.query, err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("extractAthleteFilter(%q) = %+v, want %+v", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+func auditController() {
+	router.GET("/audit", getAuditEntries)
+}
+
+// auditActor makes all writes of a request record the caller given by the X-Actor header, or its address if the header
+// is missing, together with the called endpoint in the audit log.
+func auditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := c.GetHeader("X-Actor")
+		if actor == "" {
+			actor = c.ClientIP()
+		}
+
+		ctx := service.WithAuditActor(c.Request.Context(), actor, c.Request.Method+" "+c.FullPath())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func getAuditEntries(c *gin.Context) {
+	var id primitive.ObjectID
+	if c.Query("id") != "" {
+		var convErr error
+		id, convErr = primitive.ObjectIDFromHex(c.Query("id"))
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+			return
+		}
+	}
+
+	entries, page, err := service.GetAuditEntries(c.Query("entity"), id, c.Query("meeting"), extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, entries)
+}
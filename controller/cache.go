@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/service"
+	"net/http"
+	"strings"
+)
+
+// conditionalGet tags responses with an ETag built from the request and the change counter of the meeting given by
+// the meetingParam path parameter, or of all data without one. A client sending a current ETag in If-None-Match gets
+// 304 Not Modified without the query being run.
+func conditionalGet(meetingParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		meeting := ""
+		if meetingParam != "" {
+			meeting = c.Param(meetingParam)
+		}
+
+		version, err := service.GetChangeVersion(meeting)
+		if err != nil {
+			// without version the response is just not tagged
+			c.Next()
+			return
+		}
+
+		hash := sha256.Sum256([]byte(c.Request.URL.RequestURI() + "\n" + c.GetHeader("Accept")))
+		etag := fmt.Sprintf(`W/"%d-%x"`, version, hash[:8])
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "no-cache")
+		c.Writer.Header().Add("Vary", "Accept")
+
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+		c.Next()
+	}
+}
+
+// etagMatches compares the ETags of an If-None-Match header weakly with the current one.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// headList answers HEAD requests of lists with the headers set by conditionalGet only.
+func headList(c *gin.Context) {
+	c.Status(http.StatusOK)
+}
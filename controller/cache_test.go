@@ -0,0 +1,29 @@
+package controller
+
+import "testing"
+
+func TestEtagMatches(t *testing.T) {
+	etag := `W/"12-0a1b2c3d4e5f6071"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", "", false},
+		{"same etag", `W/"12-0a1b2c3d4e5f6071"`, true},
+		{"strong form of etag", `"12-0a1b2c3d4e5f6071"`, true},
+		{"older version", `W/"11-0a1b2c3d4e5f6071"`, false},
+		{"other request", `W/"12-ffffffffffffffff"`, false},
+		{"list containing etag", `W/"11-0a1b2c3d4e5f6071", W/"12-0a1b2c3d4e5f6071"`, true},
+		{"wildcard", "*", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := etagMatches(test.ifNoneMatch, etag); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -10,172 +10,189 @@ import (
 )
 
 func certificateController() {
-	router.GET("/certificate", getCertificates)
+	router.GET("/certificate", conditionalGet(""), getCertificates)
 
 	router.GET("/certificate/amount", getCertificatesAmount)
 	router.GET("/certificate/meet/:meet_id/amount", getCertificatesAmountByMeeting)
 
-	router.GET("/certificate/:id", getCertificate)
-	router.GET("/certificate/athlete/:athlete_id", getCertificatesByAthlete)
-	router.GET("/certificate/athlete/:athlete_id/meet/:meet_id", getCertificatesByAthleteAndMeeting)
+	router.GET("/certificate/:id", conditionalGet(""), getCertificate)
+	router.GET("/certificate/athlete/:athlete_id", conditionalGet(""), getCertificatesByAthlete)
+	router.GET("/certificate/athlete/:athlete_id/meet/:meet_id", conditionalGet("meet_id"), getCertificatesByAthleteAndMeeting)
 
 	router.DELETE("/certificate/:id", removeCertificate)
-	router.POST("/certificate", addCertificate)
-	router.POST("/certificate/import", importCertificate)
+	router.POST("/certificate", idempotent(), addCertificate)
+	router.POST("/certificate/import", idempotent(), importCertificate)
 	router.PUT("/certificate", updateCertificate)
 }
 
 func getCertificates(c *gin.Context) {
-	certificates, err := service.GetCertificates()
+	certificates, page, err := service.GetCertificates(extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, certificates)
+	respondList(c, page, certificates)
 }
 
 func getCertificatesAmount(c *gin.Context) {
 	starts, err := service.GetCertificatesAmount()
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, starts)
+	c.JSON(http.StatusOK, starts)
 }
 
 func getCertificatesAmountByMeeting(c *gin.Context) {
 	meeting := c.Param("meet_id")
 	if meeting == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
 		return
 	}
 
 	starts, err := service.GetCertificatesAmountByMeeting(meeting)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, starts)
+	c.JSON(http.StatusOK, starts)
 }
 
 func getCertificatesByAthlete(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("athlete_id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given athlete_id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given athlete_id was not of type ObjectID"})
 		return
 	}
 
-	certificates, err := service.GetCertificatesByAthleteId(id)
+	certificates, page, err := service.GetCertificatesByAthleteId(id, extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, certificates)
+	respondList(c, page, certificates)
 }
 
 func getCertificatesByAthleteAndMeeting(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("athlete_id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given athlete_id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given athlete_id was not of type ObjectID"})
 		return
 	}
 
 	meeting := c.Param("meet_id")
 	if meeting == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
 		return
 	}
 
-	certificates, err := service.GetCertificatesByAthleteIdAndMeeting(id, meeting)
+	certificates, page, err := service.GetCertificatesByAthleteIdAndMeeting(id, meeting, extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, certificates)
+	respondList(c, page, certificates)
 }
 
 func getCertificate(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
 		return
 	}
 
 	certificate, err := service.GetCertificateById(id)
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, certificate)
+	c.JSON(http.StatusOK, certificate)
 }
 
 func removeCertificate(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
 		return
 	}
 
-	err := service.RemoveCertificateById(id)
+	err := service.RemoveCertificateById(c.Request.Context(), id)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusNoContent, "")
+	c.JSON(http.StatusNoContent, "")
 }
 
 func addCertificate(c *gin.Context) {
 	var certificate model.Certificate
 	if err := c.BindJSON(&certificate); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	r, err := service.AddCertificate(certificate)
+	r, err := service.AddCertificate(c.Request.Context(), certificate)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusCreated, r)
+	c.JSON(http.StatusCreated, r)
 }
 
 func updateCertificate(c *gin.Context) {
 	var certificate model.Certificate
 	if err := c.BindJSON(&certificate); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	r, err := service.UpdateCertificate(certificate)
+	r, err := service.UpdateCertificate(c.Request.Context(), certificate)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, r)
+	c.JSON(http.StatusOK, r)
 }
 
 func importCertificate(c *gin.Context) {
 	var request dto.ImportCertificateRequestDto
 	if err := c.BindJSON(&request); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	cert, err := service.ImportCertificate(request)
+	if isDryRun(c) {
+		plan, err := service.PlanCertificateImport(request)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	ctx, err := importContext(c, request.Meeting, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	cert, err := service.ImportCertificate(ctx, request)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusCreated, cert)
+	c.JSON(http.StatusCreated, cert)
 
 }
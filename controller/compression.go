@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// compress gzips the response body for clients accepting it. Event streams are left uncompressed, so every event
+// reaches the client as soon as it is flushed.
+func compress() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept-Encoding")
+
+		if c.Request.Method == http.MethodHead || strings.HasPrefix(c.Request.URL.Path, "/stream/") ||
+			!strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer writer.close()
+
+		c.Next()
+	}
+}
+
+// gzipResponseWriter starts compressing with the first write of the body, so responses without body like 304 Not
+// Modified stay empty.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	gzip *gzip.Writer
+}
+
+func (w *gzipResponseWriter) start() {
+	if w.gzip != nil {
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+
+	w.gzip = gzipWriters.Get().(*gzip.Writer)
+	w.gzip.Reset(w.ResponseWriter)
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	w.start()
+	return w.gzip.Write(data)
+}
+
+// WriteHeaderNow starts compressing before the headers are sent by streams writing them ahead of the body, responses
+// that must not have a body stay uncompressed.
+func (w *gzipResponseWriter) WriteHeaderNow() {
+	if status := w.Status(); status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified {
+		w.start()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.gzip != nil {
+		_ = w.gzip.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipResponseWriter) close() {
+	if w.gzip == nil {
+		return
+	}
+	_ = w.gzip.Close()
+	gzipWriters.Put(w.gzip)
+	w.gzip = nil
+}
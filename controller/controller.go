@@ -1,13 +1,22 @@
 package controller
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/model"
 	"github.com/swimresults/athlete-service/service"
 	ginprometheus "github.com/zsais/go-gin-prometheus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
 var router = gin.Default()
@@ -25,10 +34,17 @@ func Run() {
 		Subsystem: "gin",
 	})
 	p.Use(router)
+	router.Use(auditActor())
+	router.Use(compress())
 
 	athleteController()
 	teamController()
 	certificateController()
+	importController()
+	meetingController()
+	auditController()
+	webhookController()
+	streamController()
 
 	router.GET("/actuator", actuator)
 
@@ -40,13 +56,160 @@ func Run() {
 }
 
 func extractPagingParams(c *gin.Context) service.Paging {
-	limit := 1000
-	offset := 0
-	query := ""
-	limit, _ = strconv.Atoi(c.Query("limit"))
-	offset, _ = strconv.Atoi(c.Query("offset"))
-	query = c.Query("query")
-	return service.Paging{Limit: limit, Offset: offset, Query: query}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	paging := service.Paging{Limit: limit, Offset: offset, Query: c.Query("query"), Cursor: c.Query("cursor"), Sort: c.Query("sort"), Fields: service.ParseFields(c.Query("fields"))}
+
+	if strings.Contains(c.GetHeader("Accept"), "application/x-ndjson") {
+		paging.Stream = &ndjsonStream{c: c}
+	}
+	return paging
+}
+
+// respondList returns a page of a list together with its paging headers, a streamed list was already sent.
+func respondList(c *gin.Context, page service.Page, list interface{}) {
+	if c.Writer.Written() {
+		return
+	}
+
+	setPageHeaders(c, page)
+	c.JSON(http.StatusOK, list)
+}
+
+// respondListError returns the error of a failed list. A stream that already started cannot change its status
+// anymore, it just ends early.
+func respondListError(c *gin.Context, err error) {
+	if c.Writer.Written() {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(listErrorStatus(err), gin.H{"message": err.Error()})
+}
+
+// ndjsonStream sends the documents of a list straight from the database as one JSON object per line. It flushes
+// every hundred lines, so clients can process them while the rest is still read. A stream sends at most
+// service.MaxStreamSize documents.
+type ndjsonStream struct {
+	c     *gin.Context
+	lines int
+}
+
+func (s *ndjsonStream) Start(total int64) error {
+	s.c.Header("Content-Type", "application/x-ndjson")
+	s.c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	s.c.Status(http.StatusOK)
+	s.c.Writer.WriteHeaderNow()
+	return nil
+}
+
+func (s *ndjsonStream) Send(document interface{}) error {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	if _, err := s.c.Writer.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	s.lines++
+	if s.lines%100 == 0 {
+		s.c.Writer.Flush()
+	}
+	return nil
+}
+
+// setPageHeaders returns the total count of a list in the X-Total-Count header and links its first and next page in
+// the Link header.
+func setPageHeaders(c *gin.Context, page service.Page) {
+	c.Header("X-Total-Count", strconv.FormatInt(page.Total, 10))
+
+	query := c.Request.URL.Query()
+	query.Del("offset")
+	query.Del("cursor")
+	links := []string{pageLink(c, query, "first")}
+
+	if page.Next != "" {
+		query.Set("cursor", page.Next)
+		links = append(links, pageLink(c, query, "next"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+func pageLink(c *gin.Context, query url.Values, rel string) string {
+	link := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", link.String(), rel)
+}
+
+// listErrorStatus returns the status code of a failed list request, only an invalid cursor, sort or fields are caused
+// by the client.
+func listErrorStatus(err error) int {
+	return readErrorStatus(err, http.StatusInternalServerError)
+}
+
+// readErrorStatus returns the status code of a failed read, which is fallback unless the client asked for invalid
+// paging or fields.
+func readErrorStatus(err error, fallback int) int {
+	if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSort) || errors.Is(err, service.ErrInvalidFields) ||
+		errors.Is(err, service.ErrInvalidInclude) {
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// isDryRun reports whether an import should only be planned without writing anything.
+func isDryRun(c *gin.Context) bool {
+	return c.Query("dry_run") == "true"
+}
+
+// importContext returns the request context carrying the import batch given by the batch query parameter or the
+// X-Import-Batch header. If none is given and createBatch is set, a new batch for the meeting is created.
+// The used batch is returned in the X-Import-Batch response header.
+func importContext(c *gin.Context, meeting string, createBatch bool) (context.Context, error) {
+	ctx := c.Request.Context()
+
+	batch := c.Query("batch")
+	if batch == "" {
+		batch = c.GetHeader("X-Import-Batch")
+	}
+
+	var batchId primitive.ObjectID
+	if batch != "" {
+		id, err := primitive.ObjectIDFromHex(batch)
+		if err != nil {
+			return nil, fmt.Errorf("given batch was not of type ObjectID")
+		}
+
+		existing, err := service.GetImportBatchById(id)
+		if err != nil {
+			return nil, err
+		}
+		if !existing.RolledBackAt.IsZero() {
+			return nil, fmt.Errorf("given batch was already rolled back")
+		}
+		batchId = existing.Identifier
+	} else if createBatch {
+		created, err := service.AddImportBatch(meeting)
+		if err != nil {
+			return nil, err
+		}
+		batchId = created.Identifier
+	} else {
+		return ctx, nil
+	}
+
+	c.Header("X-Import-Batch", batchId.Hex())
+	return service.WithImportBatch(ctx, batchId), nil
+}
+
+// manualContext returns the request context for writes of data entered by hand.
+func manualContext(c *gin.Context) context.Context {
+	return service.WithProvenanceSource(c.Request.Context(), model.SourceManual)
+}
+
+// isAsync reports whether an import should run as background job.
+func isAsync(c *gin.Context) bool {
+	return c.Query("async") == "true"
 }
 
 func actuator(c *gin.Context) {
@@ -58,3 +221,30 @@ func actuator(c *gin.Context) {
 	}
 	c.String(http.StatusOK, state)
 }
+
+// bindJsonOrNdjson reads the request body either as JSON array or, for application/x-ndjson, as one JSON object per line.
+func bindJsonOrNdjson[T any](c *gin.Context) ([]T, error) {
+	var items []T
+
+	if !strings.HasPrefix(c.ContentType(), "application/x-ndjson") {
+		err := c.ShouldBindJSON(&items)
+		return items, err
+	}
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var item T
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		items = append(items, item)
+	}
+
+	return items, scanner.Err()
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testContext returns a gin context for a request with the given headers and body.
+func testContext(method string, target string, headers map[string]string, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+	return c, recorder
+}
+
+func TestBindJsonOrNdjson(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+		wantErr     string
+	}{
+		{
+			name:        "json array",
+			contentType: "application/json",
+			body:        `[{"meeting":"IESC13"},{"meeting":"IESC14"}]`,
+			want:        []string{"IESC13", "IESC14"},
+		},
+		{
+			name:        "ndjson with blank lines",
+			contentType: "application/x-ndjson",
+			body:        "{\"meeting\":\"IESC13\"}\n\n  \n{\"meeting\":\"IESC14\"}\n",
+			want:        []string{"IESC13", "IESC14"},
+		},
+		{
+			name:        "ndjson with charset",
+			contentType: "application/x-ndjson; charset=utf-8",
+			body:        `{"meeting":"IESC13"}`,
+			want:        []string{"IESC13"},
+		},
+		{
+			name:        "invalid ndjson line",
+			contentType: "application/x-ndjson",
+			body:        "{\"meeting\":\"IESC13\"}\n\n{meeting}\n",
+			wantErr:     "line 3:",
+		},
+		{
+			name:        "json object instead of array",
+			contentType: "application/json",
+			body:        `{"meeting":"IESC13"}`,
+			wantErr:     "cannot unmarshal",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := testContext(http.MethodPost, "/athlete/import/bulk", map[string]string{"Content-Type": test.contentType}, test.body)
+
+			items, err := bindJsonOrNdjson[dto.ImportAthleteRequestDto](c)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var meetings []string
+			for _, item := range items {
+				meetings = append(meetings, item.Meeting)
+			}
+			if strings.Join(meetings, ",") != strings.Join(test.want, ",") {
+				t.Errorf("got %v, want %v", meetings, test.want)
+			}
+		})
+	}
+}
+
+func TestSetPageHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		page      service.Page
+		wantTotal string
+		wantLink  string
+	}{
+		{
+			name:      "last page",
+			target:    "/athlete?limit=20",
+			page:      service.Page{Total: 12},
+			wantTotal: "12",
+			wantLink:  `</athlete?limit=20>; rel="first"`,
+		},
+		{
+			name:      "next page replaces cursor and offset",
+			target:    "/athlete/meet/IESC13?limit=20&offset=40&cursor=old&sort=-year",
+			page:      service.Page{Total: 120, Next: "next"},
+			wantTotal: "120",
+			wantLink:  `</athlete/meet/IESC13?limit=20&sort=-year>; rel="first", </athlete/meet/IESC13?cursor=next&limit=20&sort=-year>; rel="next"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, recorder := testContext(http.MethodGet, test.target, nil, "")
+			setPageHeaders(c, test.page)
+
+			if got := recorder.Header().Get("X-Total-Count"); got != test.wantTotal {
+				t.Errorf("got X-Total-Count %s, want %s", got, test.wantTotal)
+			}
+			if got := recorder.Header().Get("Link"); got != test.wantLink {
+				t.Errorf("got Link %s, want %s", got, test.wantLink)
+			}
+		})
+	}
+}
+
+func TestNdjsonStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		documents []interface{}
+		want      string
+	}{
+		{"empty list", nil, ""},
+		{"one line per document", []interface{}{gin.H{"name": "Meier"}, gin.H{"name": "Müller"}},
+			"{\"name\":\"Meier\"}\n{\"name\":\"Müller\"}\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, recorder := testContext(http.MethodGet, "/athlete", map[string]string{"Accept": "application/x-ndjson"}, "")
+			paging := extractPagingParams(c)
+			if paging.Stream == nil {
+				t.Fatal("no stream for accepted ndjson")
+			}
+
+			if err := paging.Stream.Start(int64(len(test.documents))); err != nil {
+				t.Fatal(err)
+			}
+			for _, document := range test.documents {
+				if err := paging.Stream.Send(document); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := recorder.Header().Get("Content-Type"); got != "application/x-ndjson" {
+				t.Errorf("got Content-Type %s, want application/x-ndjson", got)
+			}
+			if got, want := recorder.Header().Get("X-Total-Count"), strconv.Itoa(len(test.documents)); got != want {
+				t.Errorf("got X-Total-Count %s, want %s", got, want)
+			}
+			if got := recorder.Body.String(); got != test.want {
+				t.Errorf("got body %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/model"
+	"github.com/swimresults/athlete-service/service"
+	"io"
+	"net/http"
+)
+
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent replays the stored response for requests repeating an Idempotency-Key header. A key that is still in use
+// by a running request results in 409, a key reused with a different request in 422.
+// Requests failing with a server error or a panic release their key again, so they can be retried.
+func idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.URL.RawQuery))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		id := c.Request.Method + " " + c.Request.URL.Path + " " + key
+
+		record, exists, err := service.ReserveIdempotencyKey(id, requestHash)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		if exists {
+			if record.RequestHash != requestHash {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"message": "given Idempotency-Key was used for a different request"})
+				return
+			}
+			if !record.Completed {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "request with given Idempotency-Key is still in progress"})
+				return
+			}
+
+			for name, value := range record.Headers {
+				c.Header(name, value)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.Status, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		// the key stays reserved while the request runs, it can be taken over once the instance stopped renewing it
+		renewing, stopRenewing := context.WithCancel(context.Background())
+		defer stopRenewing()
+		go service.RenewIdempotencyKey(renewing, id, record.CreatedAt)
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// a panicking handler releases the key as well, the panic is passed on to the recovery of gin
+		defer func() {
+			if r := recover(); r != nil {
+				_ = service.ReleaseIdempotencyKey(id, record.CreatedAt)
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			_ = service.ReleaseIdempotencyKey(id, record.CreatedAt)
+			return
+		}
+
+		headers := map[string]string{}
+		if batch := recorder.Header().Get("X-Import-Batch"); batch != "" {
+			headers["X-Import-Batch"] = batch
+		}
+
+		_ = service.CompleteIdempotencyKey(model.IdempotencyRecord{
+			Identifier:  id,
+			RequestHash: requestHash,
+			Status:      recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Headers:     headers,
+			Body:        recorder.body.Bytes(),
+			CreatedAt:   record.CreatedAt,
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func importController() {
+	router.POST("/import/spreadsheet", idempotent(), importSpreadsheet)
+
+	router.POST("/import/batch", idempotent(), addImportBatch)
+	router.GET("/import/batch/:id", getImportBatch)
+	router.DELETE("/import/batch/:id", rollbackImportBatch)
+
+	router.GET("/import/job/:id", getImportJob)
+
+	router.GET("/import/conflict", getImportConflicts)
+}
+
+func importSpreadsheet(c *gin.Context) {
+	meeting := c.Query("meeting")
+	if meeting == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meeting is empty"})
+		return
+	}
+
+	var mapping dto.SpreadsheetColumnMappingDto
+	if err := json.Unmarshal([]byte(c.PostForm("mapping")), &mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given mapping is not valid: " + err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	dryRun := isDryRun(c) || c.Query("preview") == "true"
+
+	var ctx context.Context = c.Request.Context()
+	if !dryRun {
+		ctx, err = importContext(c, meeting, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	if isAsync(c) {
+		job, err := service.AddSpreadsheetImportJob(ctx, data, mapping, meeting, dryRun)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	r, err := service.ImportSpreadsheet(ctx, data, mapping, meeting, dryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func addImportBatch(c *gin.Context) {
+	meeting := c.Query("meeting")
+	if meeting == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meeting is empty"})
+		return
+	}
+
+	r, err := service.AddImportBatch(meeting)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, r)
+}
+
+func getImportBatch(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	batch, err := service.GetImportBatchById(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+func rollbackImportBatch(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	r, err := service.RollbackImportBatch(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func getImportJob(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	job, err := service.GetImportJobById(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func getImportConflicts(c *gin.Context) {
+	var id primitive.ObjectID
+	if c.Query("entity_id") != "" {
+		var convErr error
+		id, convErr = primitive.ObjectIDFromHex(c.Query("entity_id"))
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "given entity_id was not of type ObjectID"})
+			return
+		}
+	}
+
+	conflicts, page, err := service.GetImportConflicts(c.Query("entity"), id, c.Query("meeting"), extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, conflicts)
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/service"
+	"net/http"
+)
+
+func meetingController() {
+	router.GET("/meet", conditionalGet(""), getMeetings)
+	router.GET("/meet/:meet_id/summary", conditionalGet("meet_id"), getMeetingSummary)
+
+	router.DELETE("/meet/:meet_id", removeMeeting)
+	router.POST("/meet/:meet_id/rename", renameMeeting)
+	router.GET("/meet/:meet_id/rename", getMeetingRename)
+}
+
+func removeMeeting(c *gin.Context) {
+	r, err := service.RemoveMeeting(manualContext(c), c.Param("meet_id"), c.Query("certificates"), c.Query("target"), isDryRun(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func renameMeeting(c *gin.Context) {
+	var request dto.MeetingRenameRequestDto
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	r, err := service.RenameMeeting(manualContext(c), c.Param("meet_id"), request.To, isDryRun(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func getMeetingRename(c *gin.Context) {
+	rename, err := service.GetMeetingRename(c.Param("meet_id"))
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rename)
+}
+
+func getMeetings(c *gin.Context) {
+	meetings, page, err := service.GetMeetings(extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, meetings)
+}
+
+func getMeetingSummary(c *gin.Context) {
+	summary, err := service.GetMeetingSummary(c.Param("meet_id"))
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/model"
+	"github.com/swimresults/athlete-service/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"time"
+)
+
+func streamController() {
+	router.GET("/stream/meet/:meet_id", streamMeeting)
+}
+
+// streamMeeting sends the changes of all athletes, teams and certificates of the meeting as Server-Sent Events. A
+// client reconnecting with the Last-Event-ID header first receives the events it missed, together with the events of
+// the last two minutes before its last event, which it has to skip if it already received them. If they are not
+// available anymore, it receives a reset event instead and has to reload the data of the meeting.
+func streamMeeting(c *gin.Context) {
+	meeting := c.Param("meet_id")
+	lastEventId := c.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.Query("last_event_id")
+	}
+
+	// subscribing before the missed events are read makes sure no event is lost in between, events received by both
+	// are only sent once
+	events, unsubscribe := service.SubscribeMeetingEvents(meeting)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	replayed := map[primitive.ObjectID]bool{}
+	if lastEventId != "" {
+		err := service.ReplayMeetingEvents(c.Request.Context(), meeting, lastEventId, func(event model.Event) error {
+			replayed[event.Identifier] = true
+			return writeServerSentEvent(c, event)
+		})
+		if errors.Is(err, service.ErrUnknownLastEvent) {
+			err = writeStreamReset(c)
+		}
+		if err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if replayed[event.Identifier] {
+				delete(replayed, event.Identifier)
+				continue
+			}
+			if writeServerSentEvent(c, event) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeServerSentEvent(c *gin.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.Identifier.Hex(), event.Type, data)
+	return err
+}
+
+// writeStreamReset tells the client that the events it missed cannot be sent. The empty id clears its last event id, so
+// it does not ask for them again when reconnecting.
+func writeStreamReset(c *gin.Context) error {
+	data, err := json.Marshal(gin.H{"message": service.ErrUnknownLastEvent.Error()})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: \nevent: reset\ndata: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"testing"
+)
+
+func TestWriteServerSentEvent(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("65f1c0de0000000000000001")
+
+	tests := []struct {
+		name  string
+		write func(c *gin.Context) error
+		want  string
+	}{
+		{
+			name: "event",
+			write: func(c *gin.Context) error {
+				return writeServerSentEvent(c, model.Event{Identifier: id, Type: model.EventAthleteCreated, Meeting: "IESC13"})
+			},
+			want: "id: 65f1c0de0000000000000001\nevent: athlete.created\n" +
+				`data: {"_id":"65f1c0de0000000000000001","type":"athlete.created","entity_id":"000000000000000000000000","meeting":"IESC13","created_at":"0001-01-01T00:00:00Z"}` + "\n\n",
+		},
+		{
+			name:  "reset",
+			write: func(c *gin.Context) error { return writeStreamReset(c) },
+			want:  "id: \nevent: reset\n" + `data: {"message":"given last event id is unknown or expired"}` + "\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, recorder := testContext(http.MethodGet, "/stream/meet/IESC13", nil, "")
+			if err := test.write(c); err != nil {
+				t.Fatal(err)
+			}
+			if got := recorder.Body.String(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
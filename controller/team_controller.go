@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/swimresults/athlete-service/dto"
 	"github.com/swimresults/athlete-service/model"
@@ -10,155 +11,353 @@ import (
 )
 
 func teamController() {
-	router.GET("/team", getTeams)
-	router.GET("/team/:id", getTeam)
+	router.GET("/team", conditionalGet(""), getTeams)
+	router.GET("/team/:id", conditionalGet(""), getTeam)
 
 	router.GET("/team/amount", getTeamsAmount)
 	router.GET("/team/meet/:meet_id/amount", getTeamsAmountByMeeting)
 
-	router.GET("/team/meet/:meet_id", getTeamsByMeeting)
+	router.GET("/team/meet/:meet_id", conditionalGet("meet_id"), getTeamsByMeeting)
 	router.GET("/team/name", getTeamByName)
 	router.GET("/team/alias", getTeamByAlias)
-	router.POST("/team", addTeam)
-	router.POST("/team/import", importTeam)
+	router.POST("/team", idempotent(), addTeam)
+	router.POST("/team/import", idempotent(), importTeam)
+	router.POST("/team/import/bulk", idempotent(), importTeams)
+	router.POST("/team/batch", getTeamsByBatch)
 
-	router.HEAD("/team", getTeams)
-	router.HEAD("/team/:id", getTeam)
+	router.GET("/team/:id/provenance", getTeamProvenance)
+	router.GET("/team/:id/conflict", getTeamConflicts)
+	router.POST("/team/:id/lock", lockTeamFields)
+	router.POST("/team/:id/unlock", unlockTeamFields)
+	router.POST("/team/:id/revert", revertTeam)
+
+	router.HEAD("/team", conditionalGet(""), headList)
+	router.HEAD("/team/:id", conditionalGet(""), headTeam)
 }
 
 func getTeams(c *gin.Context) {
-	teams, err := service.GetTeams(extractPagingParams(c))
+	teams, page, err := service.GetTeams(extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, teams)
+	respondList(c, page, teams)
 }
 
 func getTeam(c *gin.Context) {
 	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
 	if convErr != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
 		return
 	}
 
-	team, err := service.GetTeamById(id)
+	team, err := service.GetTeamWithFieldsById(id, service.ParseFields(c.Query("fields")))
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		c.JSON(readErrorStatus(err, http.StatusNotFound), gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, team)
+	c.JSON(http.StatusOK, team)
+}
+
+// headTeam answers HEAD requests of a team with its existence and the headers set by conditionalGet.
+func headTeam(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	exists, err := service.TeamExists(id)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	c.Status(http.StatusOK)
 }
 
 func getTeamsAmount(c *gin.Context) {
 	starts, err := service.GetTeamsAmount()
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, starts)
+	c.JSON(http.StatusOK, starts)
 }
 
 func getTeamsAmountByMeeting(c *gin.Context) {
 	meeting := c.Param("meet_id")
 	if meeting == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
 		return
 	}
 
 	starts, err := service.GetTeamsAmountByMeeting(meeting)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, starts)
+	c.JSON(http.StatusOK, starts)
 }
 
 func getTeamsByMeeting(c *gin.Context) {
 	id := c.Param("meet_id")
 	if id == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given meet_id is empty"})
 		return
 	}
 
-	teams, err := service.GetTeamsByMeeting(id, extractPagingParams(c))
+	teams, page, err := service.GetTeamsByMeeting(id, extractPagingParams(c))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, teams)
+	respondList(c, page, teams)
 }
 
 func getTeamByName(c *gin.Context) {
 	name := c.Query("name")
 	if name == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given name was empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given name was empty"})
 		return
 	}
 
 	team, err := service.GetTeamByName(name)
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, team)
+	c.JSON(http.StatusOK, team)
 }
 
 func getTeamByAlias(c *gin.Context) {
 	name := c.Query("alias")
 	if name == "" {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "given alias was empty"})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given alias was empty"})
 		return
 	}
 
 	team, err := service.GetTeamByAlias(name)
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, team)
+	c.JSON(http.StatusOK, team)
 }
 
 func addTeam(c *gin.Context) {
 	var team model.Team
 	if err := c.BindJSON(&team); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	r, err := service.AddTeam(team)
+	r, err := service.AddTeam(manualContext(c), team)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusCreated, r)
+	c.JSON(http.StatusCreated, r)
 }
 
 func importTeam(c *gin.Context) {
 	var team model.Team
 	var request dto.ImportTeamRequestDto
 	if err := c.BindJSON(&request); err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	team, r, err := service.ImportTeam(request.Team, request.Meeting)
+	if isDryRun(c) {
+		plan, err := service.PlanTeamImport(request.Team, request.Meeting)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	ctx, err := importContext(c, request.Meeting, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if request.Source != "" {
+		ctx = service.WithProvenanceSource(ctx, request.Source)
+	}
+
+	team, r, err := service.ImportTeam(ctx, request.Team, request.Meeting)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
 	if r {
-		c.IndentedJSON(http.StatusCreated, team)
+		c.JSON(http.StatusCreated, team)
 	} else {
-		c.IndentedJSON(http.StatusOK, team)
+		c.JSON(http.StatusOK, team)
+	}
+}
+
+func importTeams(c *gin.Context) {
+	requests, err := bindJsonOrNdjson[dto.ImportTeamRequestDto](c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if (isDryRun(c) && !isAsync(c)) || len(requests) == 0 {
+		c.JSON(http.StatusOK, service.ImportTeams(c.Request.Context(), requests, true))
+		return
+	}
+
+	var ctx context.Context = c.Request.Context()
+	if !isDryRun(c) {
+		ctx, err = importContext(c, requests[0].Meeting, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	if isAsync(c) {
+		job, err := service.AddTeamImportJob(ctx, requests, isDryRun(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ImportTeams(ctx, requests, false))
+}
+
+func getTeamProvenance(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
 	}
+
+	provenance, err := service.GetProvenance("team", id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, provenance)
+}
+
+func getTeamConflicts(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	conflicts, page, err := service.GetImportConflicts("team", id, c.Query("meeting"), extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, conflicts)
+}
+
+func lockTeamFields(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	var request dto.FieldLockRequestDto
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	r, err := service.LockTeamFields(manualContext(c), id, request.Fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func unlockTeamFields(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	var request dto.FieldLockRequestDto
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	r, err := service.UnlockTeamFields(manualContext(c), id, request.Fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func revertTeam(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	version, convErr := primitive.ObjectIDFromHex(c.Query("version"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given version was not of type ObjectID"})
+		return
+	}
+
+	r, err := service.RevertTeam(manualContext(c), id, version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+func getTeamsByBatch(c *gin.Context) {
+	var request dto.BatchLookupRequestDto
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	result, err := service.GetTeamsByBatch(request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/swimresults/athlete-service/model"
+	"github.com/swimresults/athlete-service/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+func webhookController() {
+	router.GET("/webhook", getWebhooks)
+	router.GET("/webhook/:id", getWebhook)
+	router.GET("/webhook/:id/delivery", getWebhookDeliveries)
+
+	router.POST("/webhook", addWebhook)
+	router.DELETE("/webhook/:id", removeWebhook)
+	router.POST("/webhook/delivery/:id/replay", replayWebhookDelivery)
+}
+
+func getWebhooks(c *gin.Context) {
+	webhooks, page, err := service.GetWebhooks(extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, webhooks)
+}
+
+func getWebhook(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	webhook, err := service.GetWebhookById(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func getWebhookDeliveries(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	deliveries, page, err := service.GetWebhookDeliveries(id, c.Query("status"), extractPagingParams(c))
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondList(c, page, deliveries)
+}
+
+func addWebhook(c *gin.Context) {
+	var webhook model.Webhook
+	if err := c.BindJSON(&webhook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	r, err := service.AddWebhook(webhook)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, r)
+}
+
+func removeWebhook(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	err := service.RemoveWebhookById(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "")
+}
+
+func replayWebhookDelivery(c *gin.Context) {
+	id, convErr := primitive.ObjectIDFromHex(c.Param("id"))
+	if convErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "given id was not of type ObjectID"})
+		return
+	}
+
+	r, err := service.ReplayWebhookDelivery(id)
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrWebhookDeleted) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, r)
+}
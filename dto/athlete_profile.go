@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// parts of an athlete profile which can be selected by include
+const (
+	ProfileIncludeTeam         = "team"
+	ProfileIncludeTeamHistory  = "team_history"
+	ProfileIncludeCertificates = "certificates"
+	ProfileIncludeMeetings     = "meetings"
+)
+
+var ProfileIncludes = []string{ProfileIncludeTeam, ProfileIncludeTeamHistory, ProfileIncludeCertificates, ProfileIncludeMeetings}
+
+// AthleteProfileDto contains everything shown on the page of an athlete, parts not included are left empty.
+type AthleteProfileDto struct {
+	Athlete      model.Athlete            `json:"athlete"`
+	TeamHistory  []TeamHistoryEntryDto    `json:"team_history,omitempty"`
+	Certificates []MeetingCertificatesDto `json:"certificates,omitempty"`
+	Meetings     []string                 `json:"meetings,omitempty"`
+}
+
+// TeamHistoryEntryDto is a team the athlete was assigned to, Since is when the assignment was recorded in the audit
+// log.
+type TeamHistoryEntryDto struct {
+	TeamId  primitive.ObjectID `json:"team_id"`
+	Name    string             `json:"name,omitempty"`
+	Meeting string             `json:"meeting,omitempty"`
+	Since   time.Time          `json:"since"`
+}
+
+// MeetingCertificatesDto contains the visible certificates of a meeting in their order.
+type MeetingCertificatesDto struct {
+	Meeting      string              `json:"meeting"`
+	Certificates []model.Certificate `json:"certificates"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BatchLookupRequestDto lists the ids and DSV ids of the athletes or teams to look up.
+type BatchLookupRequestDto struct {
+	Ids    []primitive.ObjectID `json:"ids,omitempty"`
+	DsvIds []int                `json:"dsv_ids,omitempty"`
+}
+
+// AthleteBatchDto contains the found athletes keyed by the requested id or DSV id and the requested ones not found.
+type AthleteBatchDto struct {
+	Athletes        map[string]model.Athlete `json:"athletes"`
+	AthletesByDsvId map[int]model.Athlete    `json:"athletes_by_dsv_id"`
+	Missing         []primitive.ObjectID     `json:"missing"`
+	MissingDsvIds   []int                    `json:"missing_dsv_ids"`
+}
+
+// TeamBatchDto contains the found teams keyed by the requested id or DSV id and the requested ones not found.
+type TeamBatchDto struct {
+	Teams         map[string]model.Team `json:"teams"`
+	TeamsByDsvId  map[int]model.Team    `json:"teams_by_dsv_id"`
+	Missing       []primitive.ObjectID  `json:"missing"`
+	MissingDsvIds []int                 `json:"missing_dsv_ids"`
+}
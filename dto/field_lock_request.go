@@ -0,0 +1,5 @@
+package dto
+
+type FieldLockRequestDto struct {
+	Fields []string `json:"fields"`
+}
@@ -5,4 +5,5 @@ import "github.com/swimresults/athlete-service/model"
 type ImportAthleteRequestDto struct {
 	Meeting string        `json:"meeting"`
 	Athlete model.Athlete `json:"athlete"`
+	Source  string        `json:"source,omitempty"`
 }
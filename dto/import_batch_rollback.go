@@ -0,0 +1,9 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type ImportBatchRollbackDto struct {
+	Batch    primitive.ObjectID `json:"batch"`
+	Deleted  int                `json:"deleted"`
+	Restored int                `json:"restored"`
+}
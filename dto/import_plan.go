@@ -0,0 +1,28 @@
+package dto
+
+import "github.com/swimresults/athlete-service/model"
+
+const (
+	ImportActionCreate = "create"
+	ImportActionMatch  = "match"
+)
+
+// ImportPlanDto describes what an import would change, it is returned instead of the imported entity on a dry run.
+type ImportPlanDto struct {
+	Action        string             `json:"action"`
+	MatchedBy     string             `json:"matched_by,omitempty"`
+	Changes       []FieldChangeDto   `json:"changes,omitempty"`
+	Conflicts     []FieldChangeDto   `json:"conflicts,omitempty"`
+	Participation bool               `json:"participation"`
+	Warnings      []string           `json:"warnings,omitempty"`
+	Athlete       *model.Athlete     `json:"athlete,omitempty"`
+	Team          *model.Team        `json:"team,omitempty"`
+	Certificate   *model.Certificate `json:"certificate,omitempty"`
+}
+
+// FieldChangeDto describes a changed field, for a conflict From is the locked value and To the imported one.
+type FieldChangeDto struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
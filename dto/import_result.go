@@ -0,0 +1,26 @@
+package dto
+
+import "github.com/swimresults/athlete-service/model"
+
+const (
+	ImportStatusCreated = "created"
+	ImportStatusMatched = "matched"
+	ImportStatusFailed  = "failed"
+	ImportStatusSkipped = "skipped"
+)
+
+type ImportAthleteResultDto struct {
+	Index   int            `json:"index"`
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Athlete *model.Athlete `json:"athlete,omitempty"`
+	Plan    *ImportPlanDto `json:"plan,omitempty"`
+}
+
+type ImportTeamResultDto struct {
+	Index  int            `json:"index"`
+	Status string         `json:"status"`
+	Error  string         `json:"error,omitempty"`
+	Team   *model.Team    `json:"team,omitempty"`
+	Plan   *ImportPlanDto `json:"plan,omitempty"`
+}
@@ -5,4 +5,5 @@ import "github.com/swimresults/athlete-service/model"
 type ImportTeamRequestDto struct {
 	Meeting string     `json:"meeting"`
 	Team    model.Team `json:"team"`
+	Source  string     `json:"source,omitempty"`
 }
@@ -0,0 +1,21 @@
+package dto
+
+const (
+	MeetingCertificatesKeep     = "keep"
+	MeetingCertificatesDelete   = "delete"
+	MeetingCertificatesReassign = "reassign"
+)
+
+// MeetingRemovalDto reports what removing a meeting changed, or would change on a dry run. WithoutParticipation counts
+// the athletes and teams that do not take part in any meeting anymore.
+type MeetingRemovalDto struct {
+	Meeting                string `json:"meeting"`
+	DryRun                 bool   `json:"dry_run"`
+	Athletes               int    `json:"athletes"`
+	Teams                  int    `json:"teams"`
+	FirstMeetingChanged    int    `json:"first_meeting_changed"`
+	WithoutParticipation   int    `json:"without_participation"`
+	CertificatesDeleted    int    `json:"certificates_deleted"`
+	CertificatesReassigned int    `json:"certificates_reassigned"`
+	CertificatesKept       int    `json:"certificates_kept"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type MeetingRenameRequestDto struct {
+	To string `json:"to"`
+}
+
+type MeetingRenameDto struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	DryRun       bool   `json:"dry_run"`
+	Resumed      bool   `json:"resumed"`
+	Athletes     int    `json:"athletes"`
+	Teams        int    `json:"teams"`
+	Certificates int    `json:"certificates"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+// MeetingSummaryDto counts the athletes, teams and certificates of a meeting, NewAthletes counts the athletes whose
+// first meeting it was. The distributions are only part of the summary of a single meeting.
+type MeetingSummaryDto struct {
+	Meeting      string         `json:"meeting"`
+	Athletes     int            `json:"athletes"`
+	NewAthletes  int            `json:"new_athletes"`
+	Teams        int            `json:"teams"`
+	Certificates int            `json:"certificates"`
+	Genders      map[string]int `json:"genders,omitempty"`
+	Years        map[int]int    `json:"years,omitempty"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+// SpreadsheetColumnMappingDto describes which column of an uploaded entry list holds which value.
+// A column can be given as letter ("B"), as 1-based index ("2") or as the caption of the header row ("Name").
+type SpreadsheetColumnMappingDto struct {
+	Name      string `json:"name,omitempty"`
+	Firstname string `json:"firstname,omitempty"`
+	Lastname  string `json:"lastname,omitempty"`
+	Year      string `json:"year"`
+	Gender    string `json:"gender,omitempty"`
+	Club      string `json:"club"`
+	DsvId     string `json:"dsv_id,omitempty"`
+	Header    bool   `json:"header"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "github.com/swimresults/athlete-service/model"
+
+type SpreadsheetImportResponseDto struct {
+	Meeting string                    `json:"meeting"`
+	DryRun  bool                      `json:"dry_run"`
+	Created int                       `json:"created"`
+	Matched int                       `json:"matched"`
+	Failed  int                       `json:"failed"`
+	Skipped int                       `json:"skipped"`
+	Rows    []SpreadsheetRowResultDto `json:"rows"`
+}
+
+type SpreadsheetRowResultDto struct {
+	Row      int            `json:"row"`
+	Status   string         `json:"status"`
+	Message  string         `json:"message,omitempty"`
+	Athlete  *model.Athlete `json:"athlete,omitempty"`
+	Team     *model.Team    `json:"team,omitempty"`
+	Plan     *ImportPlanDto `json:"plan,omitempty"`
+	TeamPlan *ImportPlanDto `json:"team_plan,omitempty"`
+}
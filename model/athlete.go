@@ -17,4 +17,5 @@ type Athlete struct {
 	Team          Team               `json:"team,omitempty" bson:"-"`                                // DSV-File + PDF
 	FirstMeeting  string             `json:"first_meeting,omitempty" bson:"first_meeting,omitempty"` // automatically
 	Participation []string           `json:"participation,omitempty" bson:"participation,omitempty"` // automatically
+	Locked        []string           `json:"locked,omitempty" bson:"locked,omitempty"`               // manually
 }
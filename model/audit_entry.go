@@ -0,0 +1,39 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	AuditActionAdd           = "add"
+	AuditActionUpdate        = "update"
+	AuditActionImport        = "import"
+	AuditActionParticipation = "participation"
+	AuditActionDelete        = "delete"
+	AuditActionLock          = "lock"
+	AuditActionUnlock        = "unlock"
+	AuditActionRevert        = "revert"
+	AuditActionRemoveMeeting = "remove_meeting"
+	AuditActionRenameMeeting = "rename_meeting"
+	AuditActionRollback      = "rollback"
+)
+
+// AuditEntry records a single write of an athlete, team or certificate with the changed fields.
+type AuditEntry struct {
+	Identifier primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Actor      string             `json:"actor,omitempty" bson:"actor,omitempty"`
+	Endpoint   string             `json:"endpoint,omitempty" bson:"endpoint,omitempty"`
+	Action     string             `json:"action,omitempty" bson:"action,omitempty"`
+	Entity     string             `json:"entity,omitempty" bson:"entity,omitempty"`
+	EntityId   primitive.ObjectID `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Meeting    string             `json:"meeting,omitempty" bson:"meeting,omitempty"`
+	Changes    []FieldChange      `json:"changes,omitempty" bson:"changes,omitempty"`
+	CreatedAt  time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	From  interface{} `json:"from,omitempty" bson:"from,omitempty"`
+	To    interface{} `json:"to,omitempty" bson:"to,omitempty"`
+}
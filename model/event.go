@@ -0,0 +1,42 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	EventAthleteCreated       = "athlete.created"
+	EventAthleteUpdated       = "athlete.updated"
+	EventAthleteDeleted       = "athlete.deleted"
+	EventTeamCreated          = "team.created"
+	EventTeamUpdated          = "team.updated"
+	EventTeamDeleted          = "team.deleted"
+	EventCertificateCreated   = "certificate.created"
+	EventCertificateUpdated   = "certificate.updated"
+	EventCertificateDeleted   = "certificate.deleted"
+	EventParticipationAdded   = "participation.added"
+	EventParticipationRemoved = "participation.removed"
+)
+
+var EventTypes = []string{
+	EventAthleteCreated, EventAthleteUpdated, EventAthleteDeleted,
+	EventTeamCreated, EventTeamUpdated, EventTeamDeleted,
+	EventCertificateCreated, EventCertificateUpdated, EventCertificateDeleted,
+	EventParticipationAdded, EventParticipationRemoved,
+}
+
+// Event is published for every change of an athlete, team or certificate, Data holds the stored document after the
+// change and is empty for deleted entities. Meetings lists all meetings the entity belongs to.
+type Event struct {
+	Identifier primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Type       string             `json:"type,omitempty" bson:"type,omitempty"`
+	Entity     string             `json:"entity,omitempty" bson:"entity,omitempty"`
+	EntityId   primitive.ObjectID `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Meeting    string             `json:"meeting,omitempty" bson:"meeting,omitempty"`
+	Meetings   []string           `json:"meetings,omitempty" bson:"meetings,omitempty"`
+	Changes    []FieldChange      `json:"changes,omitempty" bson:"changes,omitempty"`
+	Data       bson.M             `json:"data,omitempty" bson:"data,omitempty"`
+	CreatedAt  time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// IdempotencyRecord stores the response of a request sent with an Idempotency-Key header to replay it for retries.
+type IdempotencyRecord struct {
+	Identifier   string            `json:"_id,omitempty" bson:"_id,omitempty"`
+	RequestHash  string            `json:"request_hash,omitempty" bson:"request_hash,omitempty"`
+	Completed    bool              `json:"completed,omitempty" bson:"completed,omitempty"`
+	Status       int               `json:"status,omitempty" bson:"status,omitempty"`
+	ContentType  string            `json:"content_type,omitempty" bson:"content_type,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty" bson:"headers,omitempty"`
+	Body         []byte            `json:"body,omitempty" bson:"body,omitempty"`
+	CreatedAt    time.Time         `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	PendingUntil time.Time         `json:"pending_until,omitempty" bson:"pending_until,omitempty"` // taken over afterwards unless completed
+}
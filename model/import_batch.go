@@ -0,0 +1,28 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type ImportBatch struct {
+	Identifier   primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Meeting      string             `json:"meeting,omitempty" bson:"meeting,omitempty"`
+	Changes      int                `json:"changes" bson:"-"`
+	CreatedAt    time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	RolledBackAt time.Time          `json:"rolled_back_at,omitempty" bson:"rolled_back_at,omitempty"`
+}
+
+// ImportBatchChange records an entity touched by an import of a batch, Before holds the document prior to the import
+// and is empty for created entities.
+type ImportBatchChange struct {
+	Identifier   primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	BatchId      primitive.ObjectID `json:"batch_id,omitempty" bson:"batch_id,omitempty"`
+	Entity       string             `json:"entity,omitempty" bson:"entity,omitempty"`
+	EntityId     primitive.ObjectID `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Created      bool               `json:"created,omitempty" bson:"created,omitempty"`
+	Before       bson.M             `json:"before,omitempty" bson:"before,omitempty"`
+	AddedAt      time.Time          `json:"added_at,omitempty" bson:"added_at,omitempty"`
+	RolledBackAt time.Time          `json:"rolled_back_at,omitempty" bson:"rolled_back_at,omitempty"`
+}
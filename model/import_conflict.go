@@ -0,0 +1,20 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// ImportConflict records an import that brought a value for a locked field which differs from the stored one. Only the
+// latest conflicting value per field is kept.
+type ImportConflict struct {
+	Identifier primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Entity     string             `json:"entity,omitempty" bson:"entity,omitempty"`
+	EntityId   primitive.ObjectID `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Field      string             `json:"field,omitempty" bson:"field,omitempty"`
+	Locked     interface{}        `json:"locked,omitempty" bson:"locked,omitempty"`
+	Incoming   interface{}        `json:"incoming,omitempty" bson:"incoming,omitempty"`
+	Source     string             `json:"source,omitempty" bson:"source,omitempty"`
+	Meeting    string             `json:"meeting,omitempty" bson:"meeting,omitempty"`
+	UpdatedAt  time.Time          `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
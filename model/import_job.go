@@ -0,0 +1,54 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type ImportJob struct {
+	Identifier primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Kind       string             `json:"kind,omitempty" bson:"kind,omitempty"` // athlete, team or spreadsheet
+	Meeting    string             `json:"meeting,omitempty" bson:"meeting,omitempty"`
+	Batch      primitive.ObjectID `json:"batch,omitempty" bson:"batch,omitempty"`
+	DryRun     bool               `json:"dry_run" bson:"dry_run"`
+	Actor      string             `json:"actor,omitempty" bson:"actor,omitempty"`
+	Endpoint   string             `json:"endpoint,omitempty" bson:"endpoint,omitempty"`
+	Status     string             `json:"status,omitempty" bson:"status,omitempty"` // queued, running or finished
+	Owner      string             `json:"-" bson:"owner,omitempty"`
+	LeaseUntil time.Time          `json:"-" bson:"lease_until,omitempty"`
+	Total      int                `json:"total" bson:"total"`
+	Processed  int                `json:"processed" bson:"processed"`
+	Created    int                `json:"created" bson:"created"`
+	Matched    int                `json:"matched" bson:"matched"`
+	Failed     int                `json:"failed" bson:"failed"`
+	Skipped    int                `json:"skipped" bson:"skipped"`
+	Results    []ImportJobResult  `json:"results,omitempty" bson:"-"`
+	CreatedAt  time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	StartedAt  time.Time          `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	FinishedAt time.Time          `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// ImportJobItem is a single record of an import job, an athlete import with its team or a team import, together with
+// its result. Items are stored apart from their job, so the size of a job is not limited by the size of a document.
+type ImportJobItem struct {
+	Identifier primitive.ObjectID `bson:"_id,omitempty"`
+	JobId      primitive.ObjectID `bson:"job_id"`
+	Index      int                `bson:"index"`
+	Row        int                `bson:"row,omitempty"`
+	Meeting    string             `bson:"meeting,omitempty"`
+	Athlete    *Athlete           `bson:"athlete,omitempty"`
+	Team       *Team              `bson:"team,omitempty"`
+	Source     string             `bson:"source,omitempty"`
+	Skipped    bool               `bson:"skipped,omitempty"`
+	Error      string             `bson:"error,omitempty"`
+	Result     ImportJobResult    `bson:"result"`
+}
+
+// ImportJobResult is the outcome of an item, items without status are not processed yet.
+type ImportJobResult struct {
+	Index    int                `json:"index" bson:"index"`
+	Row      int                `json:"row,omitempty" bson:"row,omitempty"`
+	Status   string             `json:"status,omitempty" bson:"status,omitempty"`
+	Error    string             `json:"error,omitempty" bson:"error,omitempty"`
+	EntityId primitive.ObjectID `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// MeetingRename tracks a rename of a meeting, which is applied in batches. As long as CompletedAt is empty the entities
+// of the meeting are split between both ids.
+type MeetingRename struct {
+	From        string    `json:"from" bson:"_id"`
+	To          string    `json:"to" bson:"to"`
+	StartedAt   time.Time `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	CompletedAt time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
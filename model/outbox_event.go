@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// OutboxEvent is an event written together with the change it describes, it stays in the outbox until all publishers
+// received it. Published lists the publishers that already did.
+type OutboxEvent struct {
+	Event         `bson:",inline"`
+	Published     []string  `bson:"published,omitempty"`
+	Done          bool      `bson:"done"`
+	Attempts      int       `bson:"attempts"`
+	Error         string    `bson:"error,omitempty"`
+	NextAttemptAt time.Time `bson:"next_attempt_at,omitempty"`
+	PublishedAt   time.Time `bson:"published_at,omitempty"`
+}
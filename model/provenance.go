@@ -0,0 +1,29 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	SourceDsv         = "dsv"
+	SourceLenex       = "lenex"
+	SourcePdf         = "pdf"
+	SourceSpreadsheet = "spreadsheet"
+	SourceManual      = "manual"
+	SourceApi         = "api"
+)
+
+// Provenance records for every field of an athlete or team where its current value comes from.
+type Provenance struct {
+	Identifier primitive.ObjectID         `json:"_id,omitempty" bson:"_id,omitempty"`
+	Entity     string                     `json:"entity,omitempty" bson:"entity,omitempty"`
+	EntityId   primitive.ObjectID         `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Fields     map[string]FieldProvenance `json:"fields,omitempty" bson:"fields,omitempty"`
+}
+
+type FieldProvenance struct {
+	Source    string    `json:"source,omitempty" bson:"source,omitempty"`
+	Meeting   string    `json:"meeting,omitempty" bson:"meeting,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
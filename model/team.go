@@ -16,4 +16,5 @@ type Team struct {
 	ColorSet      ColorSet           `json:"color_set,omitempty" bson:"color_set,omitempty"`         // manually
 	FirstMeeting  string             `json:"first_meeting,omitempty" bson:"first_meeting,omitempty"` // automatically
 	Participation []string           `json:"participation,omitempty" bson:"participation,omitempty"` // automatically
+	Locked        []string           `json:"locked,omitempty" bson:"locked,omitempty"`               // manually
 }
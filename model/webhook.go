@@ -0,0 +1,38 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook subscribes an url to events, an event filter is either an event type, a prefix like "athlete.*" or "*" for
+// all events. If a meeting is set, only events of entities belonging to that meeting are delivered.
+type Webhook struct {
+	Identifier primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Url        string             `json:"url,omitempty" bson:"url,omitempty"`
+	Secret     string             `json:"secret,omitempty" bson:"secret,omitempty"`
+	Events     []string           `json:"events,omitempty" bson:"events,omitempty"`
+	Meeting    string             `json:"meeting,omitempty" bson:"meeting,omitempty"`
+	CreatedAt  time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
+
+// WebhookDelivery is an event sent to a webhook. DeliveredAt is set once the delivery is finished, also if it failed.
+type WebhookDelivery struct {
+	Identifier     primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	WebhookId      primitive.ObjectID `json:"webhook_id,omitempty" bson:"webhook_id,omitempty"`
+	Event          Event              `json:"event" bson:"event"`
+	Status         string             `json:"status,omitempty" bson:"status,omitempty"`
+	Attempts       int                `json:"attempts" bson:"attempts"`
+	ResponseStatus int                `json:"response_status,omitempty" bson:"response_status,omitempty"`
+	Error          string             `json:"error,omitempty" bson:"error,omitempty"`
+	ReplayOf       primitive.ObjectID `json:"replay_of,omitempty" bson:"replay_of,omitempty"`
+	NextAttemptAt  time.Time          `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	DeliveredAt    time.Time          `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
 	"github.com/swimresults/athlete-service/model"
 	"github.com/swimresults/service-core/misc"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
 	"time"
 )
 
@@ -20,16 +22,98 @@ var athleteLogFields log.Fields
 func athleteService(database *mongo.Database) {
 	athleteCollection = database.Collection("athlete")
 	athleteLogFields = log.Fields{"sr_service": "athlete"}
+
+	ensureUniqueDsvIdIndex(athleteCollection)
+	ensureIndexes(athleteCollection,
+		mongo.IndexModel{Keys: bson.D{{"participation", 1}}},
+		mongo.IndexModel{Keys: bson.D{{"participation", 1}, {"name", 1}}, Options: options.Index().SetCollation(nameCollation)},
+		mongo.IndexModel{Keys: bson.D{{"participation", 1}, {"lastname", 1}, {"firstname", 1}}, Options: options.Index().SetCollation(nameCollation)},
+		mongo.IndexModel{Keys: bson.D{{"team_id", 1}, {"name", 1}}, Options: options.Index().SetCollation(nameCollation)},
+		mongo.IndexModel{Keys: bson.D{{"gender", 1}, {"year", 1}}, Options: options.Index().SetCollation(nameCollation)},
+		mongo.IndexModel{Keys: bson.D{{"first_meeting", 1}}, Options: options.Index().SetCollation(nameCollation)},
+	)
+}
+
+// athleteSortFields are the fields athlete lists can be sorted by. Last and first name are sorted by the other one
+// next, like start lists. Athletes are not sorted by their team, only its id is stored with them, which does not give a
+// meaningful order.
+var athleteSortFields = map[string][]string{
+	"name":          {"name"},
+	"lastname":      {"lastname", "firstname"},
+	"firstname":     {"firstname", "lastname"},
+	"year":          {"year"},
+	"gender":        {"gender"},
+	"dsv_id":        {"dsv_id"},
+	"first_meeting": {"first_meeting"},
 }
 
-func getAthletesByBsonDocument(d interface{}) ([]model.Athlete, error) {
-	return getAthletesByBsonDocumentWithOptions(d, options.FindOptions{})
+// AthleteFilter restricts athlete lists to the athletes matching all given values, zero values do not filter.
+type AthleteFilter struct {
+	Gender       string
+	YearMin      int
+	YearMax      int
+	TeamCountry  string
+	StateId      int
+	HasDsvId     *bool
+	FirstMeeting string
 }
 
-func getAthletesByBsonDocumentWithOptions(d interface{}, fOps options.FindOptions) ([]model.Athlete, error) {
+// document translates the filter into a query, team values are resolved to the ids of the matching teams.
+func (f AthleteFilter) document(ctx context.Context) (bson.D, error) {
+	d := bson.D{}
+	if f.Gender != "" {
+		d = append(d, bson.E{Key: "gender", Value: f.Gender})
+	}
+
+	year := bson.D{}
+	if f.YearMin > 0 {
+		year = append(year, bson.E{Key: "$gte", Value: f.YearMin})
+	}
+	if f.YearMax > 0 {
+		year = append(year, bson.E{Key: "$lte", Value: f.YearMax})
+	}
+	if len(year) > 0 {
+		d = append(d, bson.E{Key: "year", Value: year})
+	}
+
+	if f.HasDsvId != nil {
+		hasDsvId := bson.D{{"$gt", 0}}
+		if !*f.HasDsvId {
+			hasDsvId = bson.D{{"$not", hasDsvId}}
+		}
+		d = append(d, bson.E{Key: "dsv_id", Value: hasDsvId})
+	}
+
+	if f.FirstMeeting != "" {
+		d = append(d, bson.E{Key: "first_meeting", Value: f.FirstMeeting})
+	}
+
+	if f.TeamCountry != "" || f.StateId != 0 {
+		team := bson.D{}
+		if f.TeamCountry != "" {
+			team = append(team, bson.E{Key: "country", Value: f.TeamCountry})
+		}
+		if f.StateId != 0 {
+			team = append(team, bson.E{Key: "state_id", Value: f.StateId})
+		}
+
+		ids, err := teamCollection.Distinct(ctx, "_id", team)
+		if err != nil {
+			return nil, err
+		}
+		d = append(d, bson.E{Key: "team_id", Value: bson.D{{"$in", ids}}})
+	}
+	return d, nil
+}
+
+func getAthletesByBsonDocument(ctx context.Context, d interface{}) ([]model.Athlete, error) {
+	return getAthletesByBsonDocumentWithOptions(ctx, d, options.FindOptions{})
+}
+
+func getAthletesByBsonDocumentWithOptions(ctx context.Context, d interface{}, fOps options.FindOptions) ([]model.Athlete, error) {
 	var athletes []model.Athlete
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	fOps.SetSort(bson.D{{"name", 1}})
@@ -44,13 +128,7 @@ func getAthletesByBsonDocumentWithOptions(d interface{}, fOps options.FindOption
 		var athlete model.Athlete
 		cursor.Decode(&athlete)
 
-		var team model.Team
-		team, err = GetTeamById(athlete.TeamId)
-		if err == nil {
-			athlete.Team = team
-		}
-
-		athletes = append(athletes, athlete)
+		athletes = append(athletes, withTeam(ctx, athlete, nil))
 	}
 
 	if err := cursor.Err(); err != nil {
@@ -60,6 +138,74 @@ func getAthletesByBsonDocumentWithOptions(d interface{}, fOps options.FindOption
 	return athletes, nil
 }
 
+// getAthletePage returns a page of the athletes matching the query and the filter, ordered by the sort of the paging
+// or by name, see findPage.
+func getAthletePage(ctx context.Context, d interface{}, filter AthleteFilter, paging Paging) ([]model.Athlete, Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, paging.timeout())
+	defer cancel()
+
+	sort, err := paging.sortDocument(athleteSortFields, bson.D{{"name", 1}})
+	if err != nil {
+		return []model.Athlete{}, Page{}, err
+	}
+
+	f, err := filter.document(ctx)
+	if err != nil {
+		return []model.Athlete{}, Page{}, err
+	}
+	if len(f) > 0 {
+		d = bson.D{{"$and", bson.A{d, f}}}
+	}
+
+	opts, err := athleteFindOptions(paging.Fields, sort)
+	if err != nil {
+		return []model.Athlete{}, Page{}, err
+	}
+
+	athletes, page, err := findPage(ctx, athleteCollection, d, sort, opts, paging, func(athlete model.Athlete) model.Athlete {
+		return withTeam(ctx, athlete, paging.Fields)
+	})
+	if err != nil {
+		return []model.Athlete{}, Page{}, err
+	}
+
+	return athletes, page, nil
+}
+
+// athleteFindOptions returns the options of athlete lists projected to the given fields, the sort keys are always
+// kept for the cursor of the next page.
+func athleteFindOptions(fields Fields, sort bson.D) (*options.FindOptions, error) {
+	if err := fields.validate(athleteFields); err != nil {
+		return nil, err
+	}
+	if err := fields.nested("team").validate(teamFields); err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetCollation(nameCollation)
+	if projection := fields.projection(athleteEmbedded, sortKeys(sort)...); projection != nil {
+		opts.SetProjection(projection)
+	}
+	return opts, nil
+}
+
+// athleteEmbedded maps the embedded team to its reference stored in the athlete.
+var athleteEmbedded = map[string]string{"team": "team_id"}
+
+// withTeam embeds the selected fields of the team of the athlete if it can be found. It is skipped if the team is not
+// selected.
+func withTeam(ctx context.Context, athlete model.Athlete, fields Fields) model.Athlete {
+	if !fields.includes("team") {
+		return athlete
+	}
+
+	team, err := getTeamWithFieldsById(ctx, athlete.TeamId, fields.nested("team"))
+	if err == nil {
+		athlete.Team = team
+	}
+	return athlete
+}
+
 func GetAthletesAmount() (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -84,8 +230,8 @@ func GetAthletesAmountByMeeting(meeting string) (int, error) {
 	return int(count), nil
 }
 
-func GetAthletes(paging Paging) ([]model.Athlete, error) {
-	return getAthletesByBsonDocumentWithOptions(
+func GetAthletes(filter AthleteFilter, paging Paging) ([]model.Athlete, Page, error) {
+	return getAthletePage(context.Background(),
 		bson.M{
 			"$or": []interface{}{
 				bson.M{"name": bson.M{"$regex": paging.Query, "$options": "i"}},
@@ -94,11 +240,11 @@ func GetAthletes(paging Paging) ([]model.Athlete, error) {
 				bson.M{"dsv_id": bson.M{"$regex": paging.Query, "$options": "i"}},
 				bson.M{"alias": bson.M{"$regex": paging.Query, "$options": "i"}},
 			},
-		}, paging.getPaginatedOpts())
+		}, filter, paging)
 }
 
-func GetAthletesByMeetingId(id string, paging Paging) ([]model.Athlete, error) {
-	return getAthletesByBsonDocumentWithOptions(bson.M{
+func GetAthletesByMeetingId(id string, filter AthleteFilter, paging Paging) ([]model.Athlete, Page, error) {
+	return getAthletePage(context.Background(), bson.M{
 		"$and": []interface{}{
 			bson.M{"participation": id},
 			bson.M{
@@ -111,11 +257,11 @@ func GetAthletesByMeetingId(id string, paging Paging) ([]model.Athlete, error) {
 				},
 			},
 		},
-	}, paging.getPaginatedOpts())
+	}, filter, paging)
 }
 
-func GetAthletesByMeetingAndIdList(id string, athletes []primitive.ObjectID, paging Paging) ([]model.Athlete, error) {
-	return getAthletesByBsonDocumentWithOptions(bson.M{
+func GetAthletesByMeetingAndIdList(id string, athletes []primitive.ObjectID, paging Paging) ([]model.Athlete, Page, error) {
+	return getAthletePage(context.Background(), bson.M{
 		"$and": []interface{}{
 			bson.M{"_id": bson.M{"$in": athletes}},
 			bson.M{"participation": id},
@@ -129,11 +275,11 @@ func GetAthletesByMeetingAndIdList(id string, athletes []primitive.ObjectID, pag
 				},
 			},
 		},
-	}, paging.getPaginatedOpts())
+	}, AthleteFilter{}, paging)
 }
 
-func GetAthletesByTeamAndMeeting(id primitive.ObjectID, meeting string, paging Paging) ([]model.Athlete, error) {
-	return getAthletesByBsonDocumentWithOptions(bson.M{
+func GetAthletesByTeamAndMeeting(id primitive.ObjectID, meeting string, filter AthleteFilter, paging Paging) ([]model.Athlete, Page, error) {
+	return getAthletePage(context.Background(), bson.M{
 		"$and": []interface{}{
 			bson.M{"participation": meeting},
 			bson.M{"team_id": id},
@@ -147,11 +293,11 @@ func GetAthletesByTeamAndMeeting(id primitive.ObjectID, meeting string, paging P
 				},
 			},
 		},
-	}, paging.getPaginatedOpts())
+	}, filter, paging)
 }
 
-func GetAthletesByTeamId(id primitive.ObjectID, paging Paging) ([]model.Athlete, error) {
-	return getAthletesByBsonDocumentWithOptions(bson.M{
+func GetAthletesByTeamId(id primitive.ObjectID, filter AthleteFilter, paging Paging) ([]model.Athlete, Page, error) {
+	return getAthletePage(context.Background(), bson.M{
 		"$and": []interface{}{
 			bson.M{"team_id": id},
 			bson.M{
@@ -164,11 +310,24 @@ func GetAthletesByTeamId(id primitive.ObjectID, paging Paging) ([]model.Athlete,
 				},
 			},
 		},
-	}, paging.getPaginatedOpts())
+	}, filter, paging)
+}
+
+// AthleteExists reports whether an athlete with the given id exists without reading it.
+func AthleteExists(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := athleteCollection.CountDocuments(ctx, bson.D{{"_id", id}}, options.Count().SetLimit(1))
+	return count > 0, err
 }
 
 func GetAthleteById(id primitive.ObjectID) (model.Athlete, error) {
-	athletes, err := getAthletesByBsonDocument(bson.D{{"_id", id}})
+	return getAthleteById(context.Background(), id)
+}
+
+func getAthleteById(ctx context.Context, id primitive.ObjectID) (model.Athlete, error) {
+	athletes, err := getAthletesByBsonDocument(ctx, bson.D{{"_id", id}})
 	if err != nil {
 		return model.Athlete{}, err
 	}
@@ -180,8 +339,37 @@ func GetAthleteById(id primitive.ObjectID) (model.Athlete, error) {
 	return model.Athlete{}, errors.New("no entry with given id found")
 }
 
+// GetAthleteWithFieldsById returns the athlete with only the selected fields, see Fields.
+func GetAthleteWithFieldsById(id primitive.ObjectID, fields Fields) (model.Athlete, error) {
+	if len(fields) == 0 {
+		return GetAthleteById(id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts, err := athleteFindOptions(fields, nil)
+	if err != nil {
+		return model.Athlete{}, err
+	}
+
+	var athlete model.Athlete
+	err = athleteCollection.FindOne(ctx, bson.D{{"_id", id}}, &options.FindOneOptions{Projection: opts.Projection}).Decode(&athlete)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Athlete{}, errors.New("no entry with given id found")
+	}
+	if err != nil {
+		return model.Athlete{}, err
+	}
+	return withTeam(ctx, athlete, fields), nil
+}
+
 func GetAthleteByDsvId(dsvId int) (model.Athlete, error) {
-	athletes, err := getAthletesByBsonDocument(bson.D{{"dsv_id", dsvId}})
+	return getAthleteByDsvId(context.Background(), dsvId)
+}
+
+func getAthleteByDsvId(ctx context.Context, dsvId int) (model.Athlete, error) {
+	athletes, err := getAthletesByBsonDocument(ctx, bson.D{{"dsv_id", dsvId}})
 	if err != nil {
 		return model.Athlete{}, err
 	}
@@ -194,11 +382,15 @@ func GetAthleteByDsvId(dsvId int) (model.Athlete, error) {
 }
 
 func GetAthleteByNameAndYear(name string, year int) (model.Athlete, error) {
+	return getAthleteByNameAndYear(context.Background(), name, year)
+}
+
+func getAthleteByNameAndYear(ctx context.Context, name string, year int) (model.Athlete, error) {
 	if hasComma, first, last := misc.ExtractNames(name); hasComma {
 		name = first + " " + last
 	}
 
-	athletes, err := getAthletesByBsonDocument(bson.M{
+	athletes, err := getAthletesByBsonDocument(ctx, bson.M{
 		"$and": []interface{}{
 			bson.M{"year": year},
 			bson.M{
@@ -222,7 +414,7 @@ func GetAthleteByNameAndYear(name string, year int) (model.Athlete, error) {
 }
 
 func GetAthleteByAliasAndYear(alias string, year int) (model.Athlete, error) {
-	athletes, err := getAthletesByBsonDocument(bson.M{
+	athletes, err := getAthletesByBsonDocument(context.Background(), bson.M{
 		"$and": []interface{}{
 			bson.M{"year": year},
 			bson.M{"alias": bson.M{"$regex": alias, "$options": "i"}},
@@ -240,12 +432,27 @@ func GetAthleteByAliasAndYear(alias string, year int) (model.Athlete, error) {
 	return model.Athlete{}, errors.New("no entry found")
 }
 
-func RemoveAthleteById(id primitive.ObjectID) error {
+func RemoveAthleteById(ctx context.Context, id primitive.ObjectID) error {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	_, err := athleteCollection.DeleteOne(ctx, bson.D{{"_id", id}})
+	// deleting an athlete that does not exist succeeds without recording a change
+	var before model.Athlete
+	err := findDocumentById(ctx, athleteCollection, id, &before)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = athleteCollection.DeleteOne(ctx, bson.D{{"_id", id}})
+	if err != nil {
+		return err
+	}
+
+	err = recordChange(ctx, model.AuditActionDelete, "athlete", id, before, nil)
 	if err != nil {
 		return err
 	}
@@ -255,8 +462,8 @@ func RemoveAthleteById(id primitive.ObjectID) error {
 	return nil
 }
 
-func AddAthlete(athlete model.Athlete) (model.Athlete, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func AddAthlete(ctx context.Context, athlete model.Athlete) (model.Athlete, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	athlete.TeamId = athlete.Team.Identifier
@@ -273,23 +480,32 @@ func AddAthlete(athlete model.Athlete) (model.Athlete, error) {
 	if err != nil {
 		return model.Athlete{}, err
 	}
+	athlete.Identifier = r.InsertedID.(primitive.ObjectID)
+
+	err = recordChange(ctx, model.AuditActionAdd, "athlete", athlete.Identifier, nil, athlete)
+	if err != nil {
+		return model.Athlete{}, err
+	}
 
 	fields := log.Fields{"athlete": athlete}
 	log.WithFields(athleteLogFields).WithFields(fields).Info("athlete added")
 
-	return GetAthleteById(r.InsertedID.(primitive.ObjectID))
+	return getAthleteById(ctx, r.InsertedID.(primitive.ObjectID))
 }
 
-func AddParticipation(id primitive.ObjectID, meetId string) (model.Athlete, error) {
+func AddParticipation(ctx context.Context, id primitive.ObjectID, meetId string) (model.Athlete, error) {
 	fmt.Printf("add participation to athlete: %s (%s)\n", id.String(), meetId)
-	athlete, err := GetAthleteById(id)
+	ctx = withProvenanceMeeting(ctx, meetId)
+
+	athlete, err := getAthleteById(ctx, id)
 	if err != nil {
 		return model.Athlete{}, err
 	}
+	before := athlete
 
 	athlete.Participation = misc.AppendWithoutDuplicates(athlete.Participation, meetId)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	_, err = athleteCollection.ReplaceOne(ctx, bson.D{{"_id", athlete.Identifier}}, athlete)
@@ -297,126 +513,347 @@ func AddParticipation(id primitive.ObjectID, meetId string) (model.Athlete, erro
 		return model.Athlete{}, err
 	}
 
+	err = recordChange(ctx, model.AuditActionParticipation, "athlete", athlete.Identifier, before, athlete)
+	if err != nil {
+		return model.Athlete{}, err
+	}
+
 	fields := log.Fields{"athlete": athlete, "meet_id": meetId}
 	log.WithFields(athleteLogFields).WithFields(fields).Info("participation added to athlete")
 
-	return GetAthleteById(athlete.Identifier)
+	return getAthleteById(ctx, athlete.Identifier)
 }
 
-func ImportAthlete(athlete model.Athlete, meetId string) (*model.Athlete, bool, error) {
+// ImportAthlete runs in a transaction if the database supports it, so a failing participation does not leave a newly
+// created athlete behind. If the context carries an import batch the change is recorded for a later rollback.
+// Concurrent imports of the same athlete are serialized by import locks, which are also held in the database, so
+// imports on different instances cannot both create the same athlete. The unique dsv_id index and a retry additionally
+// match an athlete created with the same dsv_id by a write that did not lock.
+func ImportAthlete(ctx context.Context, athlete model.Athlete, meetId string) (*model.Athlete, bool, error) {
+	unlock, err := lockImport(athleteImportKeys(athlete)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
 
-	if athlete.Team.Name == "" && athlete.Team.DsvId == 0 && athlete.Team.Identifier.IsZero() {
-		return nil, false, fmt.Errorf("no team set in import")
+	ctx = withAuditAction(withProvenanceMeeting(ctx, meetId), model.AuditActionImport)
+
+	existing, created, err := importAthlete(ctx, athlete, meetId)
+	if mongo.IsDuplicateKeyError(err) {
+		existing, created, err = importAthlete(ctx, athlete, meetId)
+	}
+	if err != nil {
+		return nil, created, err
 	}
 
-	var existing model.Athlete
-	var err error
-	found := false
+	fields := log.Fields{"athlete": existing, "created": created}
+	log.WithFields(athleteLogFields).WithFields(fields).Info("athlete imported")
+
+	return &existing, created, nil
+
+	// if dsv_id, search by dsv_id (dsv_id '==')
+	// -> not found
+	// 		search by name, team and year (name aliasified in aliases; team (GetTeamByName), year '==')
+	//
+	// 		-> not found:	create
+	//						add participation
+	// 			=> return true
+	//
+	// -> found:	update dsv_id
+	// 				update firstname (+aliases)
+	//				update lastname (+aliases)
+	// 				update gender
+	//				add participation
+	// 			=> return false
+
+}
+
+// athleteImportKeys returns the lock keys of an imported athlete, its dsv_id and its name with year.
+func athleteImportKeys(athlete model.Athlete) []string {
+	name := athlete.Name
+	if hasComma, first, last := misc.ExtractNames(name); hasComma {
+		name = first + " " + last
+	}
+
+	keys := []string{fmt.Sprintf("athlete:name:%s:%d", misc.Aliasify(name), athlete.Year)}
 	if athlete.DsvId != 0 {
-		existing, err = GetAthleteByDsvId(athlete.DsvId)
+		keys = append(keys, fmt.Sprintf("athlete:dsv_id:%d", athlete.DsvId))
+	}
+	return keys
+}
 
+func importAthlete(ctx context.Context, athlete model.Athlete, meetId string) (model.Athlete, bool, error) {
+	var existing model.Athlete
+	var created bool
+
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		plan, err := planAthleteImport(ctx, athlete, meetId)
 		if err != nil {
-			if err.Error() != "no entry with given dsv_id found" {
-				return nil, false, err
+			return err
+		}
+		created = !plan.found
+
+		existing = plan.athlete
+		if plan.found {
+			fmt.Printf("import of athlete '%s', already present\n", athlete.Name)
+
+			err = recordImportBatchChange(ctx, athleteCollection, "athlete", existing.Identifier, false)
+			if err != nil {
+				return err
+			}
+
+			err = recordImportConflicts(ctx, "athlete", existing.Identifier, plan.conflicts)
+			if err != nil {
+				return err
+			}
+
+			if len(plan.changes) > 0 {
+				fmt.Printf("updating some values...\n")
+				existing, err = UpdateAthlete(ctx, existing)
+				if err != nil {
+					return err
+				}
 			}
 		} else {
-			found = true
+			fmt.Printf("import of athlete '%s', not existing so far\n", athlete.Name)
+
+			if plan.teamErr != nil {
+				return plan.teamErr
+			}
+
+			existing, err = AddAthlete(ctx, existing)
+			if err != nil {
+				return err
+			}
+
+			err = recordImportBatchChange(ctx, athleteCollection, "athlete", existing.Identifier, true)
+			if err != nil {
+				return err
+			}
 		}
+
+		existing, err = AddParticipation(ctx, existing.Identifier, meetId)
+		return err
+	})
+
+	return existing, created, err
+}
+
+// PlanAthleteImport runs the matching of ImportAthlete and returns the planned changes without writing anything.
+func PlanAthleteImport(athlete model.Athlete, meetId string) (dto.ImportPlanDto, error) {
+	plan, err := planAthleteImport(context.Background(), athlete, meetId)
+	if err != nil {
+		return dto.ImportPlanDto{}, err
+	}
+	return plan.dto(), nil
+}
+
+type athleteImportPlan struct {
+	athlete       model.Athlete
+	found         bool
+	matchedBy     string
+	changes       []dto.FieldChangeDto
+	conflicts     []dto.FieldChangeDto
+	participation bool
+	warnings      []string
+	teamErr       error
+}
+
+func (p athleteImportPlan) dto() dto.ImportPlanDto {
+	plan := dto.ImportPlanDto{
+		Action:        dto.ImportActionCreate,
+		MatchedBy:     p.matchedBy,
+		Changes:       p.changes,
+		Conflicts:     p.conflicts,
+		Participation: p.participation,
+		Warnings:      p.warnings,
+		Athlete:       &p.athlete,
+	}
+	if p.found {
+		plan.Action = dto.ImportActionMatch
+	}
+	if !p.athlete.Team.Identifier.IsZero() {
+		plan.Team = &p.athlete.Team
+	}
+	return plan
+}
+
+// planAthleteImport searches the imported athlete and applies the values an import would fill in to the existing
+// athlete, for a new athlete the team is resolved. Locked fields are never changed, differing values for them are
+// listed as conflicts. Matches that might be wrong are listed as warnings.
+func planAthleteImport(ctx context.Context, athlete model.Athlete, meetId string) (athleteImportPlan, error) {
+	if athlete.Team.Name == "" && athlete.Team.DsvId == 0 && athlete.Team.Identifier.IsZero() {
+		return athleteImportPlan{}, fmt.Errorf("no team set in import")
+	}
+
+	existing, found, matchedBy, err := findImportedAthlete(ctx, athlete)
+	if err != nil {
+		return athleteImportPlan{}, err
 	}
 
+	plan := athleteImportPlan{found: found, matchedBy: matchedBy, participation: true}
+
 	if !found {
-		existing, err = GetAthleteByNameAndYear(athlete.Name, athlete.Year)
+		athlete.FirstMeeting = meetId
 
+		var team model.Team
+		if !athlete.Team.Identifier.IsZero() {
+			team, err = getTeamById(ctx, athlete.Team.Identifier)
+		} else if athlete.Team.DsvId != 0 {
+			team, err = getTeamByDsvId(ctx, athlete.Team.DsvId)
+		} else {
+			team, err = getTeamByName(ctx, athlete.Team.Name)
+		}
 		if err != nil {
-			if err.Error() != "no entry found" {
-				return nil, false, err
-			}
+			plan.teamErr = err
+			plan.warnings = append(plan.warnings, fmt.Sprintf("team '%s' could not be found: %s", athlete.Team.Name, err.Error()))
 		} else {
-			found = true
+			athlete.Team = team
 		}
+
+		plan.athlete = athlete
+		return plan, nil
+	}
+
+	change := func(field string, from interface{}, to interface{}) {
+		plan.changes = append(plan.changes, dto.FieldChangeDto{Field: field, From: from, To: to})
 	}
 
-	if found {
-		fmt.Printf("import of athlete '%s', already present\n", athlete.Name)
+	incoming := athlete
+	if hasNames, first, last := misc.ExtractNames(athlete.Name); hasNames && incoming.Firstname == "" && incoming.Lastname == "" {
+		incoming.Firstname = first
+		incoming.Lastname = last
+	}
+	plan.conflicts, err = lockConflicts(existing, incoming, existing.Locked)
+	if err != nil {
+		return athleteImportPlan{}, err
+	}
 
-		changed := false
-		if existing.Firstname == "" || existing.Lastname == "" {
-			if hasNames, first, last := misc.ExtractNames(athlete.Name); hasNames {
+	if existing.Firstname == "" || existing.Lastname == "" {
+		if hasNames, first, last := misc.ExtractNames(athlete.Name); hasNames {
+			if !isLocked(existing.Locked, "firstname") {
+				change("firstname", existing.Firstname, first)
 				existing.Firstname = first
+			}
+			if !isLocked(existing.Locked, "lastname") {
+				change("lastname", existing.Lastname, last)
 				existing.Lastname = last
-				changed = true
 			}
 		}
-		if existing.DsvId == 0 && athlete.DsvId != 0 {
-			existing.DsvId = athlete.DsvId
-			changed = true
-		}
-		if existing.Gender == "" && athlete.Gender != "" {
-			existing.Gender = athlete.Gender
-			changed = true
-		}
+	}
+	if existing.DsvId == 0 && athlete.DsvId != 0 && !isLocked(existing.Locked, "dsv_id") {
+		change("dsv_id", existing.DsvId, athlete.DsvId)
+		existing.DsvId = athlete.DsvId
+	}
+	if existing.Gender == "" && athlete.Gender != "" && !isLocked(existing.Locked, "gender") {
+		change("gender", existing.Gender, athlete.Gender)
+		existing.Gender = athlete.Gender
+	}
 
-		if changed {
-			fmt.Printf("updating some values...\n")
-			existing, err = UpdateAthlete(existing)
-			if err != nil {
-				return nil, false, err
-			}
+	name := athlete.Name
+	if hasComma, first, last := misc.ExtractNames(name); hasComma {
+		name = first + " " + last
+	}
+	if matchedBy == "name_year" && !strings.EqualFold(strings.TrimSpace(name), existing.Name) {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("name '%s' only partially matches '%s'", name, existing.Name))
+	}
+	if matchedBy == "name_year" && athlete.DsvId != 0 && existing.DsvId != athlete.DsvId {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("dsv_id %d differs from existing dsv_id %d", athlete.DsvId, existing.DsvId))
+	}
+	if matchedBy == "dsv_id" && (athlete.Year != existing.Year || !strings.EqualFold(strings.TrimSpace(name), existing.Name)) {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("matched by dsv_id but name or year differ ('%s' %d)", existing.Name, existing.Year))
+	}
+	if athlete.Gender != "" && existing.Gender != athlete.Gender {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("gender '%s' differs from existing gender '%s'", athlete.Gender, existing.Gender))
+	}
+	if athlete.Team.Name != "" && existing.Team.Name != "" && !strings.EqualFold(athlete.Team.Name, existing.Team.Name) {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("team '%s' differs from existing team '%s'", athlete.Team.Name, existing.Team.Name))
+	}
+
+	for _, p := range existing.Participation {
+		if p == meetId {
+			plan.participation = false
 		}
-	} else {
-		fmt.Printf("import of athlete '%s', not existing so far\n", athlete.Name)
+	}
 
-		athlete.FirstMeeting = meetId
+	plan.athlete = existing
+	return plan, nil
+}
 
-		var team model.Team
-		if !athlete.Team.Identifier.IsZero() {
-			team, err = GetTeamById(athlete.Team.Identifier)
-		} else if athlete.Team.DsvId != 0 {
-			team, err = GetTeamByDsvId(athlete.Team.DsvId)
+// ImportAthletes imports every given athlete on its own, a failing athlete does not stop the remaining ones.
+// On a dry run only the plan of every athlete is returned.
+func ImportAthletes(ctx context.Context, requests []dto.ImportAthleteRequestDto, dryRun bool) []dto.ImportAthleteResultDto {
+	results := make([]dto.ImportAthleteResultDto, len(requests))
+
+	for i, request := range requests {
+		results[i].Index = i
+
+		if request.Meeting == "" {
+			results[i].Status = dto.ImportStatusFailed
+			results[i].Error = "given meeting is empty"
+			continue
+		}
+
+		var athlete *model.Athlete
+		var created bool
+		var err error
+		if dryRun {
+			var plan dto.ImportPlanDto
+			plan, err = PlanAthleteImport(request.Athlete, request.Meeting)
+			results[i].Plan = &plan
+			athlete = plan.Athlete
+			created = plan.Action == dto.ImportActionCreate
 		} else {
-			team, err = GetTeamByName(athlete.Team.Name)
+			athlete, created, err = ImportAthlete(importSourceContext(ctx, request.Source), request.Athlete, request.Meeting)
 		}
 		if err != nil {
-			return nil, true, err
+			results[i].Status = dto.ImportStatusFailed
+			results[i].Error = err.Error()
+			results[i].Plan = nil
+			continue
 		}
 
-		athlete.Team.Identifier = team.Identifier
-
-		existing, err = AddAthlete(athlete)
-		if err != nil {
-			return nil, true, err
+		results[i].Athlete = athlete
+		if created {
+			results[i].Status = dto.ImportStatusCreated
+		} else {
+			results[i].Status = dto.ImportStatusMatched
 		}
 	}
 
-	existing, err = AddParticipation(existing.Identifier, meetId)
-	if err != nil {
-		return nil, !found, err
+	var statuses []string
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
 	}
+	log.WithFields(athleteLogFields).WithFields(importSummaryFields(dryRun, statuses)).Info("athletes imported")
 
-	fields := log.Fields{"athlete": existing, "created": !found}
-	log.WithFields(athleteLogFields).WithFields(fields).Info("athlete imported")
-
-	return &existing, !found, nil
+	return results
+}
 
-	// if dsv_id, search by dsv_id (dsv_id '==')
-	// -> not found
-	// 		search by name, team and year (name aliasified in aliases; team (GetTeamByName), year '==')
-	//
-	// 		-> not found:	create
-	//						add participation
-	// 			=> return true
-	//
-	// -> found:	update dsv_id
-	// 				update firstname (+aliases)
-	//				update lastname (+aliases)
-	// 				update gender
-	//				add participation
-	// 			=> return false
+// findImportedAthlete searches an already existing athlete for an import, first by dsv_id then by name and year.
+func findImportedAthlete(ctx context.Context, athlete model.Athlete) (model.Athlete, bool, string, error) {
+	if athlete.DsvId != 0 {
+		existing, err := getAthleteByDsvId(ctx, athlete.DsvId)
+		if err == nil {
+			return existing, true, "dsv_id", nil
+		}
+		if err.Error() != "no entry with given dsv_id found" {
+			return model.Athlete{}, false, "", err
+		}
+	}
 
+	existing, err := getAthleteByNameAndYear(ctx, athlete.Name, athlete.Year)
+	if err == nil {
+		return existing, true, "name_year", nil
+	}
+	if err.Error() != "no entry found" {
+		return model.Athlete{}, false, "", err
+	}
+	return model.Athlete{}, false, "", nil
 }
 
-func UpdateAthlete(athlete model.Athlete) (model.Athlete, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func UpdateAthlete(ctx context.Context, athlete model.Athlete) (model.Athlete, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	athlete.TeamId = athlete.Team.Identifier
@@ -429,7 +866,21 @@ func UpdateAthlete(athlete model.Athlete) (model.Athlete, error) {
 
 	athlete.Alias = misc.AppendWithoutDuplicates(athlete.Alias, misc.Aliasify(athlete.Name))
 
-	_, err := athleteCollection.ReplaceOne(ctx, bson.D{{"_id", athlete.Identifier}}, athlete)
+	var before model.Athlete
+	err := findDocumentById(ctx, athleteCollection, athlete.Identifier, &before)
+	if err != nil {
+		return model.Athlete{}, err
+	}
+	if athlete.Locked == nil {
+		athlete.Locked = before.Locked
+	}
+
+	_, err = athleteCollection.ReplaceOne(ctx, bson.D{{"_id", athlete.Identifier}}, athlete)
+	if err != nil {
+		return model.Athlete{}, err
+	}
+
+	err = recordChange(ctx, model.AuditActionUpdate, "athlete", athlete.Identifier, before, athlete)
 	if err != nil {
 		return model.Athlete{}, err
 	}
@@ -437,5 +888,5 @@ func UpdateAthlete(athlete model.Athlete) (model.Athlete, error) {
 	fields := log.Fields{"athlete": athlete}
 	log.WithFields(athleteLogFields).WithFields(fields).Info("athlete updated")
 
-	return GetAthleteById(athlete.Identifier)
+	return getAthleteById(ctx, athlete.Identifier)
 }
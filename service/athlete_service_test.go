@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// initTestDatabase connects to the database given by the SR_ATHLETE_MONGO_* variables and initializes the service
+// with an empty database, which is dropped after the test.
+func initTestDatabase(t *testing.T) {
+	host := os.Getenv("SR_ATHLETE_MONGO_HOST")
+	if host == "" {
+		t.Skip("SR_ATHLETE_MONGO_HOST not set, skipping database test")
+	}
+
+	uri := "mongodb://"
+	if os.Getenv("SR_ATHLETE_MONGO_USERNAME") != "" {
+		uri += os.Getenv("SR_ATHLETE_MONGO_USERNAME") + ":" + os.Getenv("SR_ATHLETE_MONGO_PASSWORD") + "@"
+	}
+	uri += host + ":" + os.Getenv("SR_ATHLETE_MONGO_PORT")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database := fmt.Sprintf("athlete_service_test_%d", time.Now().UnixNano())
+	t.Setenv("SR_ATHLETE_MONGO_DATABASE", database)
+	Init(c)
+
+	t.Cleanup(func() {
+		_ = c.Database(database).Drop(context.Background())
+		_ = c.Disconnect(context.Background())
+	})
+}
+
+func TestImportAthleteConcurrently(t *testing.T) {
+	initTestDatabase(t)
+
+	team, _, err := ImportTeam(context.Background(), model.Team{Name: "SV Test"}, "TEST01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	athletes := []model.Athlete{
+		{Name: "Meier, Simon", Year: 2010, DsvId: 123444, Team: team},
+		{Name: "Schulze, Anna", Year: 2011, Team: team},
+	}
+
+	for _, athlete := range athletes {
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, _, err := ImportAthlete(context.Background(), athlete, "TEST01"); err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Errorf("import of '%s' failed: %s", athlete.Name, err)
+		}
+
+		count, err := athleteCollection.CountDocuments(context.Background(), bson.D{{"year", athlete.Year}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected exactly one athlete '%s', found %d", athlete.Name, count)
+		}
+	}
+}
+
+func TestAthleteFilterDocument(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		name   string
+		filter AthleteFilter
+		want   bson.D
+	}{
+		{"empty", AthleteFilter{}, bson.D{}},
+		{"gender", AthleteFilter{Gender: "W"}, bson.D{{"gender", "W"}}},
+		{"year range", AthleteFilter{YearMin: 2008, YearMax: 2010}, bson.D{{"year", bson.D{{"$gte", 2008}, {"$lte", 2010}}}}},
+		{"minimum year", AthleteFilter{YearMin: 2008}, bson.D{{"year", bson.D{{"$gte", 2008}}}}},
+		{"with dsv id", AthleteFilter{HasDsvId: &yes}, bson.D{{"dsv_id", bson.D{{"$gt", 0}}}}},
+		{"without dsv id", AthleteFilter{HasDsvId: &no}, bson.D{{"dsv_id", bson.D{{"$not", bson.D{{"$gt", 0}}}}}}},
+		{
+			"combined",
+			AthleteFilter{Gender: "M", YearMax: 2012, FirstMeeting: "IESC13"},
+			bson.D{{"gender", "M"}, {"year", bson.D{{"$lte", 2012}}}, {"first_meeting", "IESC13"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.filter.document(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetAthletesCollation(t *testing.T) {
+	initTestDatabase(t)
+
+	for i, name := range []string{"Zander, Paul", "meier, Lea", "Öztürk, Can", "Muster, Max", "Otto, Eva", "Müller, Simon"} {
+		if _, _, err := ImportAthlete(context.Background(), model.Athlete{Name: name, Year: 2000 + i}, "IESC13"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"lastname", []string{"meier", "Müller", "Muster", "Öztürk", "Otto", "Zander"}},
+		{"-lastname", []string{"Zander", "Otto", "Öztürk", "Muster", "Müller", "meier"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sort, func(t *testing.T) {
+			athletes, _, err := GetAthletes(AthleteFilter{}, Paging{Sort: test.sort})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, athlete := range athletes {
+				got = append(got, athlete.Lastname)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+var auditCollection *mongo.Collection
+
+type auditKey struct{}
+
+type auditInfo struct {
+	actor    string
+	endpoint string
+	action   string
+}
+
+func auditService(database *mongo.Database) {
+	auditCollection = database.Collection("audit", documentMOptions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = auditCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"entity", 1}, {"entity_id", 1}, {"created_at", -1}}},
+		{Keys: bson.D{{"meeting", 1}, {"created_at", -1}}},
+	})
+}
+
+// WithAuditActor returns a context that makes the writes run with it record the given actor and endpoint in the audit
+// log.
+func WithAuditActor(ctx context.Context, actor string, endpoint string) context.Context {
+	info := auditFromContext(ctx)
+	info.actor = actor
+	info.endpoint = endpoint
+	return context.WithValue(ctx, auditKey{}, info)
+}
+
+// withAuditAction overrides the action of all writes run with the returned context, so the single writes of an import
+// are recorded as import.
+func withAuditAction(ctx context.Context, action string) context.Context {
+	info := auditFromContext(ctx)
+	info.action = action
+	return context.WithValue(ctx, auditKey{}, info)
+}
+
+func auditFromContext(ctx context.Context) auditInfo {
+	info, _ := ctx.Value(auditKey{}).(auditInfo)
+	return info
+}
+
+func recordAudit(ctx context.Context, changes []entityChange) error {
+	info := auditFromContext(ctx)
+
+	var entries []interface{}
+	for _, c := range changes {
+		action := c.action
+		if info.action != "" {
+			action = info.action
+		}
+
+		entries = append(entries, model.AuditEntry{
+			Actor:     info.actor,
+			Endpoint:  info.endpoint,
+			Action:    action,
+			Entity:    c.entity,
+			EntityId:  c.id,
+			Meeting:   c.meeting,
+			Changes:   c.fieldChanges(),
+			CreatedAt: time.Now(),
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := auditCollection.InsertMany(ctx, entries)
+	return err
+}
+
+// GetAuditEntries returns the audit log newest first, filtered by entity, entity id and meeting if given.
+func GetAuditEntries(entity string, id primitive.ObjectID, meeting string, paging Paging) ([]model.AuditEntry, Page, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), paging.timeout())
+	defer cancel()
+
+	filter := bson.D{}
+	if entity != "" {
+		filter = append(filter, bson.E{Key: "entity", Value: entity})
+	}
+	if !id.IsZero() {
+		filter = append(filter, bson.E{Key: "entity_id", Value: id})
+	}
+	if meeting != "" {
+		filter = append(filter, bson.E{Key: "meeting", Value: meeting})
+	}
+
+	entries, page, err := findPage[model.AuditEntry](ctx, auditCollection, filter, bson.D{{"created_at", -1}, {"_id", -1}}, nil, paging, nil)
+	if err != nil {
+		return []model.AuditEntry{}, Page{}, err
+	}
+	return entries, page, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"slices"
+	"time"
+)
+
+// MaxBatchSize is the largest number of ids and DSV ids a batch lookup accepts.
+const MaxBatchSize = 1000
+
+func batchFilter(request dto.BatchLookupRequestDto) (bson.D, error) {
+	if len(request.Ids)+len(request.DsvIds) > MaxBatchSize {
+		return nil, fmt.Errorf("at most %d ids can be looked up at once", MaxBatchSize)
+	}
+
+	ids := request.Ids
+	if ids == nil {
+		ids = []primitive.ObjectID{}
+	}
+	dsvIds := request.DsvIds
+	if dsvIds == nil {
+		dsvIds = []int{}
+	}
+
+	return bson.D{{"$or", bson.A{
+		bson.D{{"_id", bson.D{{"$in", ids}}}},
+		bson.D{{"dsv_id", bson.D{{"$in", dsvIds}}}},
+	}}}, nil
+}
+
+// GetAthletesByBatch looks up the athletes with the requested ids and DSV ids together with their teams, which are
+// read at once instead of per athlete. An athlete requested by both is returned under both keys.
+func GetAthletesByBatch(request dto.BatchLookupRequestDto) (dto.AthleteBatchDto, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter, err := batchFilter(request)
+	if err != nil {
+		return dto.AthleteBatchDto{}, err
+	}
+
+	var athletes []model.Athlete
+	if err := findAll(ctx, athleteCollection, filter, &athletes); err != nil {
+		return dto.AthleteBatchDto{}, err
+	}
+
+	var teamIds []primitive.ObjectID
+	for _, athlete := range athletes {
+		if !athlete.TeamId.IsZero() {
+			teamIds = append(teamIds, athlete.TeamId)
+		}
+	}
+	teams, err := findTeamsById(ctx, teamIds)
+	if err != nil {
+		return dto.AthleteBatchDto{}, err
+	}
+
+	result := dto.AthleteBatchDto{
+		Athletes:        map[string]model.Athlete{},
+		AthletesByDsvId: map[int]model.Athlete{},
+		Missing:         []primitive.ObjectID{},
+		MissingDsvIds:   []int{},
+	}
+	for _, athlete := range athletes {
+		athlete.Team = teams[athlete.TeamId]
+		if slices.Contains(request.Ids, athlete.Identifier) {
+			result.Athletes[athlete.Identifier.Hex()] = athlete
+		}
+		if athlete.DsvId > 0 && slices.Contains(request.DsvIds, athlete.DsvId) {
+			result.AthletesByDsvId[athlete.DsvId] = athlete
+		}
+	}
+
+	for _, id := range request.Ids {
+		if _, ok := result.Athletes[id.Hex()]; !ok {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	for _, dsvId := range request.DsvIds {
+		if _, ok := result.AthletesByDsvId[dsvId]; !ok {
+			result.MissingDsvIds = append(result.MissingDsvIds, dsvId)
+		}
+	}
+	return result, nil
+}
+
+// GetTeamsByBatch looks up the teams with the requested ids and DSV ids.
+func GetTeamsByBatch(request dto.BatchLookupRequestDto) (dto.TeamBatchDto, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter, err := batchFilter(request)
+	if err != nil {
+		return dto.TeamBatchDto{}, err
+	}
+
+	var teams []model.Team
+	if err := findAll(ctx, teamCollection, filter, &teams); err != nil {
+		return dto.TeamBatchDto{}, err
+	}
+
+	result := dto.TeamBatchDto{
+		Teams:         map[string]model.Team{},
+		TeamsByDsvId:  map[int]model.Team{},
+		Missing:       []primitive.ObjectID{},
+		MissingDsvIds: []int{},
+	}
+	for _, team := range teams {
+		if slices.Contains(request.Ids, team.Identifier) {
+			result.Teams[team.Identifier.Hex()] = team
+		}
+		if team.DsvId > 0 && slices.Contains(request.DsvIds, team.DsvId) {
+			result.TeamsByDsvId[team.DsvId] = team
+		}
+	}
+
+	for _, id := range request.Ids {
+		if _, ok := result.Teams[id.Hex()]; !ok {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	for _, dsvId := range request.DsvIds {
+		if _, ok := result.TeamsByDsvId[dsvId]; !ok {
+			result.MissingDsvIds = append(result.MissingDsvIds, dsvId)
+		}
+	}
+	return result, nil
+}
+
+func findTeamsById(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.Team, error) {
+	teams := map[primitive.ObjectID]model.Team{}
+	if len(ids) == 0 {
+		return teams, nil
+	}
+
+	var found []model.Team
+	if err := findAll(ctx, teamCollection, bson.D{{"_id", bson.D{{"$in", ids}}}}, &found); err != nil {
+		return nil, err
+	}
+	for _, team := range found {
+		teams[team.Identifier] = team
+	}
+	return teams, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+)
+
+func TestBatchFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		request dto.BatchLookupRequestDto
+		want    bson.D
+		wantErr bool
+	}{
+		{
+			name:    "ids and dsv ids",
+			request: dto.BatchLookupRequestDto{Ids: []primitive.ObjectID{id}, DsvIds: []int{4711}},
+			want: bson.D{{"$or", bson.A{
+				bson.D{{"_id", bson.D{{"$in", []primitive.ObjectID{id}}}}},
+				bson.D{{"dsv_id", bson.D{{"$in", []int{4711}}}}},
+			}}},
+		},
+		{
+			name:    "missing lists match nothing",
+			request: dto.BatchLookupRequestDto{},
+			want: bson.D{{"$or", bson.A{
+				bson.D{{"_id", bson.D{{"$in", []primitive.ObjectID{}}}}},
+				bson.D{{"dsv_id", bson.D{{"$in", []int{}}}}},
+			}}},
+		},
+		{
+			name:    "too many ids",
+			request: dto.BatchLookupRequestDto{Ids: make([]primitive.ObjectID, MaxBatchSize), DsvIds: []int{4711}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := batchFilter(test.request)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetAthletesByBatch(t *testing.T) {
+	initTestDatabase(t)
+
+	meier, err := AddAthlete(context.Background(), model.Athlete{Name: "Meier, Lea", Year: 2008, DsvId: 4711})
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := primitive.NewObjectID()
+
+	batch, err := GetAthletesByBatch(dto.BatchLookupRequestDto{
+		Ids:    []primitive.ObjectID{meier.Identifier, missing},
+		DsvIds: []int{4711, 4712},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := batch.Athletes[meier.Identifier.Hex()]; !ok {
+		t.Errorf("athlete %s not found by id", meier.Identifier.Hex())
+	}
+	if _, ok := batch.AthletesByDsvId[4711]; !ok {
+		t.Error("athlete 4711 not found by dsv id")
+	}
+	if !reflect.DeepEqual(batch.Missing, []primitive.ObjectID{missing}) {
+		t.Errorf("got missing %v, want %v", batch.Missing, []primitive.ObjectID{missing})
+	}
+	if !reflect.DeepEqual(batch.MissingDsvIds, []int{4712}) {
+		t.Errorf("got missing dsv ids %v, want [4712]", batch.MissingDsvIds)
+	}
+}
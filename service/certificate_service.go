@@ -22,14 +22,14 @@ func certificateService(database *mongo.Database) {
 	certificateLogFields = log.Fields{"sr_service": "certificate"}
 }
 
-func getCertificatesByBsonDocument(d interface{}) ([]model.Certificate, error) {
-	return getCertificatesByBsonDocumentWithOptions(d, options.FindOptions{})
+func getCertificatesByBsonDocument(ctx context.Context, d interface{}) ([]model.Certificate, error) {
+	return getCertificatesByBsonDocumentWithOptions(ctx, d, options.FindOptions{})
 }
 
-func getCertificatesByBsonDocumentWithOptions(d interface{}, fOps options.FindOptions) ([]model.Certificate, error) {
+func getCertificatesByBsonDocumentWithOptions(ctx context.Context, d interface{}, fOps options.FindOptions) ([]model.Certificate, error) {
 	var certificates []model.Certificate
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	fOps.SetSort(bson.D{{"ordering", 1}})
@@ -78,20 +78,37 @@ func GetCertificatesAmountByMeeting(meeting string) (int, error) {
 	return int(count), nil
 }
 
-func GetCertificates() ([]model.Certificate, error) {
-	return getCertificatesByBsonDocument(bson.D{})
+// getCertificatePage returns a page of the certificates matching the filter in their order, see findPage.
+func getCertificatePage(ctx context.Context, d interface{}, paging Paging) ([]model.Certificate, Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, paging.timeout())
+	defer cancel()
+
+	certificates, page, err := findPage[model.Certificate](ctx, certificateCollection, d, bson.D{{"ordering", 1}}, nil, paging, nil)
+	if err != nil {
+		return []model.Certificate{}, Page{}, err
+	}
+
+	return certificates, page, nil
 }
 
-func GetCertificatesByAthleteIdAndMeeting(id primitive.ObjectID, meeting string) ([]model.Certificate, error) {
-	return getCertificatesByBsonDocument(bson.D{{"athlete_id", id}, {"meeting", meeting}})
+func GetCertificates(paging Paging) ([]model.Certificate, Page, error) {
+	return getCertificatePage(context.Background(), bson.D{}, paging)
 }
 
-func GetCertificatesByAthleteId(id primitive.ObjectID) ([]model.Certificate, error) {
-	return getCertificatesByBsonDocument(bson.D{{"athlete_id", id}})
+func GetCertificatesByAthleteIdAndMeeting(id primitive.ObjectID, meeting string, paging Paging) ([]model.Certificate, Page, error) {
+	return getCertificatePage(context.Background(), bson.D{{"athlete_id", id}, {"meeting", meeting}}, paging)
+}
+
+func GetCertificatesByAthleteId(id primitive.ObjectID, paging Paging) ([]model.Certificate, Page, error) {
+	return getCertificatePage(context.Background(), bson.D{{"athlete_id", id}}, paging)
 }
 
 func GetCertificateById(id primitive.ObjectID) (model.Certificate, error) {
-	certificates, err := getCertificatesByBsonDocument(bson.D{{"_id", id}})
+	return getCertificateById(context.Background(), id)
+}
+
+func getCertificateById(ctx context.Context, id primitive.ObjectID) (model.Certificate, error) {
+	certificates, err := getCertificatesByBsonDocument(ctx, bson.D{{"_id", id}})
 	if err != nil {
 		return model.Certificate{}, err
 	}
@@ -103,12 +120,27 @@ func GetCertificateById(id primitive.ObjectID) (model.Certificate, error) {
 	return model.Certificate{}, errors.New("no entry with given id found")
 }
 
-func RemoveCertificateById(id primitive.ObjectID) error {
+func RemoveCertificateById(ctx context.Context, id primitive.ObjectID) error {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	_, err := certificateCollection.DeleteOne(ctx, bson.D{{"_id", id}})
+	// deleting a certificate that does not exist succeeds without recording a change
+	var before model.Certificate
+	err := findDocumentById(ctx, certificateCollection, id, &before)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = certificateCollection.DeleteOne(ctx, bson.D{{"_id", id}})
+	if err != nil {
+		return err
+	}
+
+	err = recordChange(ctx, model.AuditActionDelete, "certificate", id, before, nil)
 	if err != nil {
 		return err
 	}
@@ -118,8 +150,8 @@ func RemoveCertificateById(id primitive.ObjectID) error {
 	return nil
 }
 
-func AddCertificate(certificate model.Certificate) (model.Certificate, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func AddCertificate(ctx context.Context, certificate model.Certificate) (model.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	certificate.AddedAt = time.Now()
@@ -129,19 +161,70 @@ func AddCertificate(certificate model.Certificate) (model.Certificate, error) {
 	if err != nil {
 		return model.Certificate{}, err
 	}
+	certificate.Identifier = r.InsertedID.(primitive.ObjectID)
+
+	err = recordChange(ctx, model.AuditActionAdd, "certificate", certificate.Identifier, nil, certificate)
+	if err != nil {
+		return model.Certificate{}, err
+	}
 
 	fields := log.Fields{"certificate": certificate}
 	log.WithFields(certificateLogFields).WithFields(fields).Info("certificate added")
 
-	return GetCertificateById(r.InsertedID.(primitive.ObjectID))
+	return getCertificateById(ctx, r.InsertedID.(primitive.ObjectID))
 }
 
-func ImportCertificate(request dto.ImportCertificateRequestDto) (model.Certificate, error) {
+// ImportCertificate adds the certificate in a transaction if the database supports it. If the context carries an import
+// batch the certificate is recorded for a later rollback.
+func ImportCertificate(ctx context.Context, request dto.ImportCertificateRequestDto) (model.Certificate, error) {
+	var certificate model.Certificate
+
+	ctx = withAuditAction(ctx, model.AuditActionImport)
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		certificate, err = AddCertificate(ctx, certificateFromImport(request))
+		if err != nil {
+			return err
+		}
+
+		return recordImportBatchChange(ctx, certificateCollection, "certificate", certificate.Identifier, true)
+	})
+	if err != nil {
+		return model.Certificate{}, err
+	}
+
+	return certificate, nil
+}
+
+// PlanCertificateImport returns the certificate ImportCertificate would add without writing anything, a missing
+// athlete or an already existing certificate with the same name are listed as warnings.
+func PlanCertificateImport(request dto.ImportCertificateRequestDto) (dto.ImportPlanDto, error) {
+	certificate := certificateFromImport(request)
+	plan := dto.ImportPlanDto{Action: dto.ImportActionCreate, Certificate: &certificate}
+
+	if _, err := GetAthleteById(certificate.AthleteId); err != nil {
+		plan.Warnings = append(plan.Warnings, "athlete could not be found: "+err.Error())
+	}
+
+	existing, err := getCertificatesByBsonDocument(context.Background(), bson.D{{"athlete_id", certificate.AthleteId}, {"meeting", certificate.Meeting}})
+	if err != nil {
+		return dto.ImportPlanDto{}, err
+	}
+	for _, e := range existing {
+		if e.Name == certificate.Name {
+			plan.Warnings = append(plan.Warnings, "certificate with same name already exists: "+e.Identifier.Hex())
+		}
+	}
+
+	return plan, nil
+}
+
+func certificateFromImport(request dto.ImportCertificateRequestDto) model.Certificate {
 	if request.Path != "" && !strings.HasPrefix(request.Path, "/") {
 		request.Path = "/" + request.Path
 	}
 
-	certificate := model.Certificate{
+	return model.Certificate{
 		Name:      request.Name,
 		AthleteId: request.AthleteId,
 		Meeting:   request.Meeting,
@@ -150,17 +233,27 @@ func ImportCertificate(request dto.ImportCertificateRequestDto) (model.Certifica
 		Downloads: 0,
 		Ordering:  0,
 	}
-
-	return AddCertificate(certificate)
 }
 
-func UpdateCertificate(certificate model.Certificate) (model.Certificate, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func UpdateCertificate(ctx context.Context, certificate model.Certificate) (model.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	certificate.UpdatedAt = time.Now()
 
-	_, err := certificateCollection.ReplaceOne(ctx, bson.D{{"_id", certificate.Identifier}}, certificate)
+	// an unknown certificate fails with the same error as before, it is not created
+	var before model.Certificate
+	err := findDocumentById(ctx, certificateCollection, certificate.Identifier, &before)
+	if err != nil {
+		return model.Certificate{}, err
+	}
+
+	_, err = certificateCollection.ReplaceOne(ctx, bson.D{{"_id", certificate.Identifier}}, certificate)
+	if err != nil {
+		return model.Certificate{}, err
+	}
+
+	err = recordChange(ctx, model.AuditActionUpdate, "certificate", certificate.Identifier, before, certificate)
 	if err != nil {
 		return model.Certificate{}, err
 	}
@@ -168,5 +261,5 @@ func UpdateCertificate(certificate model.Certificate) (model.Certificate, error)
 	fields := log.Fields{"certificate": certificate}
 	log.WithFields(certificateLogFields).WithFields(fields).Info("certificate updated")
 
-	return GetCertificateById(certificate.Identifier)
+	return getCertificateById(ctx, certificate.Identifier)
 }
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"github.com/swimresults/service-core/misc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// entityChange describes a single write as it is passed to the audit log, the provenance and the published events.
+type entityChange struct {
+	action   string
+	entity   string
+	id       primitive.ObjectID
+	meeting  string
+	meetings []string
+	created  bool
+	deleted  bool
+	changes  []dto.FieldChangeDto
+	after    bson.M
+}
+
+// recordChange is called for every write of an athlete, team or certificate with the stored document before and after
+// the write, before is nil for created and after is nil for deleted entities.
+func recordChange(ctx context.Context, action string, entity string, id primitive.ObjectID, before interface{}, after interface{}) error {
+	c, err := newEntityChange(ctx, action, entity, id, before, after)
+	if err != nil {
+		return err
+	}
+	return recordChanges(ctx, []entityChange{c})
+}
+
+// recordChanges records the changes of several writes at once, with a single write to each of the audit log, the
+// provenance and the outbox.
+func recordChanges(ctx context.Context, changes []entityChange) error {
+	if err := recordAudit(ctx, changes); err != nil {
+		return err
+	}
+	if err := recordProvenance(ctx, changes); err != nil {
+		return err
+	}
+
+	countChanges(ctx, changes)
+	return publishChanges(ctx, changes)
+}
+
+func newEntityChange(ctx context.Context, action string, entity string, id primitive.ObjectID, before interface{}, after interface{}) (entityChange, error) {
+	changes, err := diffDocuments(before, after)
+	if err != nil {
+		return entityChange{}, err
+	}
+	beforeFields, err := toBsonFields(before)
+	if err != nil {
+		return entityChange{}, err
+	}
+	afterFields, err := toBsonFields(after)
+	if err != nil {
+		return entityChange{}, err
+	}
+
+	c := entityChange{
+		action:  action,
+		entity:  entity,
+		id:      id,
+		meeting: provenanceFromContext(ctx).meeting,
+		created: before == nil,
+		deleted: after == nil,
+		changes: changes,
+		after:   afterFields,
+	}
+
+	// certificates belong to a meeting themselves
+	if c.meeting == "" {
+		c.meeting = documentMeeting(afterFields)
+	}
+	if c.meeting == "" {
+		c.meeting = documentMeeting(beforeFields)
+	}
+
+	if c.meeting != "" {
+		c.meetings = append(c.meetings, c.meeting)
+	}
+	c.meetings = documentMeetings(c.meetings, beforeFields)
+	c.meetings = documentMeetings(c.meetings, afterFields)
+	return c, nil
+}
+
+// changed reports whether the write changed anything, writes that did not are audited but neither counted nor
+// published.
+func (c entityChange) changed() bool {
+	return c.created || c.deleted || len(c.changes) > 0
+}
+
+func (c entityChange) fieldChanges() []model.FieldChange {
+	var changes []model.FieldChange
+	for _, change := range c.changes {
+		changes = append(changes, model.FieldChange{Field: change.Field, From: change.From, To: change.To})
+	}
+	return changes
+}
+
+func documentMeeting(fields bson.M) string {
+	meeting, _ := fields["meeting"].(string)
+	return meeting
+}
+
+// documentMeetings adds the meeting and the participation of a stored document to meetings.
+func documentMeetings(meetings []string, fields bson.M) []string {
+	if meeting := documentMeeting(fields); meeting != "" {
+		meetings = misc.AppendWithoutDuplicates(meetings, meeting)
+	}
+	participation, _ := fields["participation"].(bson.A)
+	for _, p := range participation {
+		if meeting, ok := p.(string); ok {
+			meetings = misc.AppendWithoutDuplicates(meetings, meeting)
+		}
+	}
+	return meetings
+}
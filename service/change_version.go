@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var changeVersionCollection *mongo.Collection
+
+// allChanges is the key of the counter of all changes, meetings are counted under their id.
+const allChanges = "*"
+
+func changeVersionService(database *mongo.Database) {
+	changeVersionCollection = database.Collection("change_version")
+}
+
+// countChanges increments the change counters of all data and of the meetings of the changes. They are incremented
+// after the changes are committed, so a response read before with the old version never contains a change unnoticed.
+// Counting them within the transaction would make concurrent imports conflict on the same counter.
+func countChanges(ctx context.Context, changes []entityChange) {
+	counts := map[string]int{}
+	for _, c := range changes {
+		if !c.changed() {
+			continue
+		}
+		for _, key := range append([]string{allChanges}, c.meetings...) {
+			counts[key]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	afterCommit(ctx, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var models []mongo.WriteModel
+		for key, count := range counts {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{{"_id", key}}).
+				SetUpdate(bson.D{{"$inc", bson.D{{"version", count}}}}).
+				SetUpsert(true))
+		}
+
+		_, err := changeVersionCollection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			fields := log.Fields{"changes": len(changes), "error": err.Error()}
+			log.WithFields(fields).Error("unable to count changes")
+		}
+	})
+}
+
+// GetChangeVersion returns the number of changes of the meeting, or of all data if no meeting is given. It changes
+// with every write affecting the meeting and can be used to tell whether a response is still current.
+func GetChangeVersion(meeting string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if meeting == "" {
+		meeting = allChanges
+	}
+
+	var counter struct {
+		Version int64 `bson:"version"`
+	}
+	err := changeVersionCollection.FindOne(ctx, bson.D{{"_id", meeting}}).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return counter.Version, err
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"github.com/swimresults/athlete-service/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"sort"
+)
+
+// diffDocuments compares the top level fields of two documents as they are stored in the database, before may be nil
+// for a new document and after may be nil for a deleted one. The _id field is ignored.
+func diffDocuments(before interface{}, after interface{}) ([]dto.FieldChangeDto, error) {
+	beforeFields, err := toBsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toBsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []dto.FieldChangeDto
+	for _, name := range names {
+		if name == "_id" {
+			continue
+		}
+		if !reflect.DeepEqual(beforeFields[name], afterFields[name]) {
+			changes = append(changes, dto.FieldChangeDto{Field: name, From: beforeFields[name], To: afterFields[name]})
+		}
+	}
+	return changes, nil
+}
+
+func toBsonFields(document interface{}) (bson.M, error) {
+	fields := bson.M{}
+	if document == nil || reflect.ValueOf(document).Kind() == reflect.Ptr && reflect.ValueOf(document).IsNil() {
+		return fields, nil
+	}
+
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	err = bson.Unmarshal(data, &fields)
+	return fields, err
+}
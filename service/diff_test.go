@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	id := primitive.NewObjectID()
+	athlete := model.Athlete{Identifier: id, Name: "Simon Meier", Year: 2010, Participation: []string{"IESC13"}}
+	var deleted *model.Athlete
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   []string
+	}{
+		{
+			name:   "unchanged",
+			before: athlete,
+			after:  athlete,
+			want:   nil,
+		},
+		{
+			name:   "created",
+			before: nil,
+			after:  athlete,
+			want:   []string{"name: <nil> -> Simon Meier", "participation: <nil> -> [IESC13]", "year: <nil> -> 2010"},
+		},
+		{
+			name:   "deleted",
+			before: athlete,
+			after:  deleted,
+			want:   []string{"name: Simon Meier -> <nil>", "participation: [IESC13] -> <nil>", "year: 2010 -> <nil>"},
+		},
+		{
+			name:   "changed, added and removed fields ordered by name",
+			before: athlete,
+			after:  model.Athlete{Identifier: primitive.NewObjectID(), Name: "Simon Meier", Gender: "M", Participation: []string{"IESC13", "IESC14"}},
+			want:   []string{"gender: <nil> -> M", "participation: [IESC13] -> [IESC13 IESC14]", "year: 2010 -> <nil>"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes, err := diffDocuments(test.before, test.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, change := range changes {
+				got = append(got, fmt.Sprintf("%s: %v -> %v", change.Field, change.From, change.To))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sync"
+	"time"
+)
+
+// EventPublisher receives the events of all changes from the outbox. An event is passed again until Publish succeeded,
+// so publishers have to cope with duplicates.
+type EventPublisher interface {
+	Name() string
+	Publish(ctx context.Context, event model.Event) error
+}
+
+var publishers []EventPublisher
+var publishersMutex sync.RWMutex
+
+// RegisterPublisher adds a publisher for all events written to the outbox from now on.
+func RegisterPublisher(publisher EventPublisher) {
+	publishersMutex.Lock()
+	defer publishersMutex.Unlock()
+
+	publishers = append(publishers, publisher)
+}
+
+func registeredPublishers() []EventPublisher {
+	publishersMutex.RLock()
+	defer publishersMutex.RUnlock()
+
+	return append([]EventPublisher{}, publishers...)
+}
+
+// publishChanges writes the events of the changes to the outbox in the transaction of the changes, so an event is
+// stored if and only if its change is. Writes that did not change anything are not published.
+func publishChanges(ctx context.Context, changes []entityChange) error {
+	var events []model.Event
+	for _, c := range changes {
+		if !c.changed() {
+			continue
+		}
+
+		events = append(events, model.Event{
+			Identifier: primitive.NewObjectID(),
+			Type:       c.eventType(),
+			Entity:     c.entity,
+			EntityId:   c.id,
+			Meeting:    c.meeting,
+			Meetings:   c.meetings,
+			Changes:    c.fieldChanges(),
+			Data:       c.after,
+			CreatedAt:  time.Now(),
+		})
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	err := addOutboxEvents(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	afterCommit(ctx, triggerEventRelay)
+	afterCommit(ctx, triggerEventTail)
+	return nil
+}
+
+func (c entityChange) eventType() string {
+	switch {
+	case c.action == model.AuditActionParticipation:
+		return model.EventParticipationAdded
+	case c.action == model.AuditActionRemoveMeeting && c.entity != "certificate":
+		return model.EventParticipationRemoved
+	case c.deleted:
+		return c.entity + ".deleted"
+	case c.created:
+		return c.entity + ".created"
+	}
+	return c.entity + ".updated"
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"time"
+)
+
+const eventSubscriberBuffer = 64
+
+// eventTailWindow is how far the tail looks back for events it has not seen yet. An event only becomes visible once
+// its transaction is committed, which MongoDB allows up to a minute after the event was created.
+const eventTailWindow = 2 * time.Minute
+
+var meetingEventBroker = newEventBroker()
+var eventTailTrigger = make(chan struct{}, 1)
+
+// ErrUnknownLastEvent is returned when a stream cannot be resumed, because the last event it received is not kept
+// anymore or was never written.
+var ErrUnknownLastEvent = errors.New("given last event id is unknown or expired")
+
+// eventBroker passes the events tailed from the outbox by this instance to its subscribed streams.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan model.Event]string
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[chan model.Event]string),
+	}
+}
+
+func (b *eventBroker) publish(event model.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscriber, meeting := range b.subscribers {
+		if !eventOfMeeting(event, meeting) {
+			continue
+		}
+		select {
+		case subscriber <- event:
+		default:
+			// a subscriber that does not keep up is dropped, it catches up after reconnecting
+			delete(b.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// subscribe returns a channel receiving all further events of the meeting. The returned function ends the
+// subscription.
+func (b *eventBroker) subscribe(meeting string) (<-chan model.Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscriber := make(chan model.Event, eventSubscriberBuffer)
+	b.subscribers[subscriber] = meeting
+
+	return subscriber, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[subscriber]; ok {
+			delete(b.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+func eventOfMeeting(event model.Event, meeting string) bool {
+	for _, m := range event.Meetings {
+		if m == meeting {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscribeMeetingEvents subscribes to the changes of all athletes, teams and certificates of a meeting, see
+// eventBroker.subscribe.
+func SubscribeMeetingEvents(meeting string) (<-chan model.Event, func()) {
+	return meetingEventBroker.subscribe(meeting)
+}
+
+// ReplayMeetingEvents passes the events of the meeting written after the given one to send, oldest first. They are read
+// from the outbox, so a stream can resume on any instance as long as its last event is kept. Otherwise
+// ErrUnknownLastEvent is returned and nothing is sent. An event committed late can have a lower id than the last one
+// while it was not visible yet, so the events within the tail window before the last one are sent again as well. A
+// client has to skip the events it already received by their id.
+func ReplayMeetingEvents(ctx context.Context, meeting string, lastEventId string, send func(event model.Event) error) error {
+	id, err := primitive.ObjectIDFromHex(lastEventId)
+	if err != nil {
+		return ErrUnknownLastEvent
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	count, err := eventOutboxCollection.CountDocuments(ctx, bson.D{{"_id", id}})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUnknownLastEvent
+	}
+
+	opts := options.Find().SetSort(bson.D{{"_id", 1}})
+	since := primitive.NewObjectIDFromTimestamp(id.Timestamp().Add(-eventTailWindow))
+	cursor, err := eventOutboxCollection.Find(ctx, bson.D{{"meetings", meeting}, {"_id", bson.D{{"$gte", since}, {"$ne", id}}}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event model.Event
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func triggerEventTail() {
+	select {
+	case eventTailTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// runEventTail follows the outbox and passes every new event to the broker, so the streams of every instance receive
+// the changes written through any instance. The outbox is read every second and right after a change of this instance.
+func runEventTail() {
+	seen := map[primitive.ObjectID]bool{}
+	for {
+		if err := tailEvents(seen); err != nil {
+			log.WithFields(eventLogFields).WithFields(log.Fields{"error": err.Error()}).Error("unable to tail outbox events")
+		}
+
+		select {
+		case <-eventTailTrigger:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// tailEvents publishes the events of the outbox within the tail window that were not published before. Only their ids
+// are read to find them, seen holds the ids already published.
+func tailEvents(seen map[primitive.ObjectID]bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	since := time.Now().Add(-eventTailWindow)
+	for id := range seen {
+		if id.Timestamp().Before(since) {
+			delete(seen, id)
+		}
+	}
+
+	opts := options.Find().SetProjection(bson.D{{"_id", 1}})
+	cursor, err := eventOutboxCollection.Find(ctx, bson.D{{"_id", bson.D{{"$gte", primitive.NewObjectIDFromTimestamp(since)}}}}, opts)
+	if err != nil {
+		return err
+	}
+
+	var recent []struct {
+		Identifier primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &recent); err != nil {
+		return err
+	}
+
+	var ids []primitive.ObjectID
+	for _, event := range recent {
+		if !seen[event.Identifier] {
+			ids = append(ids, event.Identifier)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	cursor, err = eventOutboxCollection.Find(ctx, bson.D{{"_id", bson.D{{"$in", ids}}}}, options.Find().SetSort(bson.D{{"_id", 1}}))
+	if err != nil {
+		return err
+	}
+
+	var events []model.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		seen[event.Identifier] = true
+		meetingEventBroker.publish(event)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestEventOfMeeting(t *testing.T) {
+	tests := []struct {
+		meetings []string
+		meeting  string
+		want     bool
+	}{
+		{[]string{"IESC13"}, "IESC13", true},
+		{[]string{"IESC13", "IESC14"}, "IESC14", true},
+		{[]string{"IESC13"}, "IESC14", false},
+		{nil, "IESC13", false},
+		{[]string{"IESC13"}, "", false},
+	}
+
+	for _, test := range tests {
+		if got := eventOfMeeting(model.Event{Meetings: test.meetings}, test.meeting); got != test.want {
+			t.Errorf("eventOfMeeting(%v, %q) = %t, want %t", test.meetings, test.meeting, got, test.want)
+		}
+	}
+}
+
+func TestEventBroker(t *testing.T) {
+	broker := newEventBroker()
+
+	first, unsubscribeFirst := broker.subscribe("IESC13")
+	second, unsubscribeSecond := broker.subscribe("IESC14")
+	defer unsubscribeSecond()
+
+	events := []model.Event{
+		{Identifier: primitive.NewObjectID(), Meetings: []string{"IESC13"}},
+		{Identifier: primitive.NewObjectID(), Meetings: []string{"IESC14"}},
+		{Identifier: primitive.NewObjectID(), Meetings: []string{"IESC13", "IESC14"}},
+	}
+	for _, event := range events {
+		broker.publish(event)
+	}
+
+	tests := []struct {
+		name       string
+		subscriber <-chan model.Event
+		want       []primitive.ObjectID
+	}{
+		{"first meeting", first, []primitive.ObjectID{events[0].Identifier, events[2].Identifier}},
+		{"second meeting", second, []primitive.ObjectID{events[1].Identifier, events[2].Identifier}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, id := range test.want {
+				if got := <-test.subscriber; got.Identifier != id {
+					t.Errorf("got event %s, want %s", got.Identifier.Hex(), id.Hex())
+				}
+			}
+			if len(test.subscriber) != 0 {
+				t.Errorf("got %d unexpected events", len(test.subscriber))
+			}
+		})
+	}
+
+	unsubscribeFirst()
+	if _, ok := <-first; ok {
+		t.Error("expected channel to be closed after unsubscribing")
+	}
+	// unsubscribing twice must not close the channel again
+	unsubscribeFirst()
+}
+
+func TestEventBrokerDropsSlowSubscriber(t *testing.T) {
+	broker := newEventBroker()
+	events, unsubscribe := broker.subscribe("IESC13")
+	defer unsubscribe()
+
+	for i := 0; i < eventSubscriberBuffer+1; i++ {
+		broker.publish(model.Event{Identifier: primitive.NewObjectID(), Meetings: []string{"IESC13"}})
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	if received != eventSubscriberBuffer {
+		t.Errorf("received %d events before the channel was closed, want %d", received, eventSubscriberBuffer)
+	}
+}
+
+func TestReplayMeetingEvents(t *testing.T) {
+	initTestDatabase(t)
+
+	now := time.Now()
+	id := func(age time.Duration, n byte) primitive.ObjectID {
+		id := primitive.NewObjectIDFromTimestamp(now.Add(-age))
+		id[11] = n
+		return id
+	}
+
+	last := id(time.Minute, 2)
+	events := []model.Event{
+		{Identifier: id(10*time.Minute, 1), Meetings: []string{"IESC13"}},
+		{Identifier: id(90*time.Second, 1), Meetings: []string{"IESC13"}},
+		{Identifier: last, Meetings: []string{"IESC13"}},
+		{Identifier: id(30*time.Second, 1), Meetings: []string{"IESC14"}},
+		{Identifier: id(10*time.Second, 1), Meetings: []string{"IESC13", "IESC14"}},
+	}
+	if err := addOutboxEvents(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		lastEventId string
+		want        []primitive.ObjectID
+		wantErr     error
+	}{
+		{"tail window before the last event", last.Hex(), []primitive.ObjectID{events[1].Identifier, events[4].Identifier}, nil},
+		{"unknown last event", primitive.NewObjectID().Hex(), nil, ErrUnknownLastEvent},
+		{"invalid last event", "last", nil, ErrUnknownLastEvent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []primitive.ObjectID
+			err := ReplayMeetingEvents(context.Background(), "IESC13", test.lastEventId, func(event model.Event) error {
+				got = append(got, event.Identifier)
+				return nil
+			})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var eventOutboxCollection *mongo.Collection
+var eventLogFields = log.Fields{"sr_service": "event"}
+var eventRelayTrigger = make(chan struct{}, 1)
+
+// published events are kept for a week
+const eventOutboxRetention = 7 * 24 * time.Hour
+
+func eventOutboxService(database *mongo.Database) {
+	eventOutboxCollection = database.Collection("event_outbox", documentMOptions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = eventOutboxCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"done", 1}, {"next_attempt_at", 1}, {"_id", 1}}},
+		{Keys: bson.D{{"meetings", 1}, {"_id", 1}}},
+		{Keys: bson.D{{"published_at", 1}}, Options: options.Index().SetExpireAfterSeconds(int32(eventOutboxRetention.Seconds()))},
+	})
+
+	RegisterPublisher(webhookPublisher{})
+	if path := os.Getenv("SR_ATHLETE_EVENT_FILE"); path != "" {
+		RegisterPublisher(NewFilePublisher(path))
+	}
+
+	go runEventRelay()
+	go runEventTail()
+}
+
+func addOutboxEvents(ctx context.Context, events []model.Event) error {
+	var documents []interface{}
+	for _, event := range events {
+		documents = append(documents, model.OutboxEvent{Event: event, NextAttemptAt: event.CreatedAt})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := eventOutboxCollection.InsertMany(ctx, documents)
+	return err
+}
+
+func triggerEventRelay() {
+	select {
+	case eventRelayTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// runEventRelay passes the events of the outbox to the publishers. The oldest due event is relayed first, but a failed
+// event is retried later while the following ones are published and all instances relay at the same time, so
+// publishers can receive events out of order. Events are also picked up after a restart or if another instance stopped
+// while relaying them.
+func runEventRelay() {
+	for {
+		for relayNextEvent() {
+		}
+
+		select {
+		case <-eventRelayTrigger:
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// relayNextEvent claims the next due event of the outbox and publishes it, it reports whether there was one. A claimed
+// event is locked for a minute, so another instance does not relay it at the same time.
+func relayNextEvent() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var outboxEvent model.OutboxEvent
+	err := eventOutboxCollection.FindOneAndUpdate(ctx,
+		bson.D{{"done", false}, {"next_attempt_at", bson.D{{"$lte", now}}}},
+		bson.D{{"$set", bson.D{{"next_attempt_at", now.Add(time.Minute)}}}},
+		options.FindOneAndUpdate().SetSort(bson.D{{"_id", 1}}).SetReturnDocument(options.After),
+	).Decode(&outboxEvent)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.WithFields(eventLogFields).WithFields(log.Fields{"error": err.Error()}).Error("unable to claim outbox event")
+		}
+		return false
+	}
+
+	var failures []string
+	for _, publisher := range registeredPublishers() {
+		if slices.Contains(outboxEvent.Published, publisher.Name()) {
+			continue
+		}
+
+		publishCtx, publishCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := publisher.Publish(publishCtx, outboxEvent.Event)
+		publishCancel()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", publisher.Name(), err.Error()))
+			continue
+		}
+		outboxEvent.Published = append(outboxEvent.Published, publisher.Name())
+	}
+
+	outboxEvent.Attempts++
+	update := bson.D{{"published", outboxEvent.Published}, {"attempts", outboxEvent.Attempts}}
+	if len(failures) == 0 {
+		update = append(update, bson.E{Key: "done", Value: true}, bson.E{Key: "published_at", Value: time.Now()}, bson.E{Key: "error", Value: ""})
+	} else {
+		update = append(update, bson.E{Key: "next_attempt_at", Value: time.Now().Add(retryBackoff(outboxEvent.Attempts))}, bson.E{Key: "error", Value: strings.Join(failures, "; ")})
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = eventOutboxCollection.UpdateOne(ctx, bson.D{{"_id", outboxEvent.Identifier}}, bson.D{{"$set", update}})
+	if err != nil {
+		log.WithFields(eventLogFields).WithFields(log.Fields{"event_id": outboxEvent.Identifier, "error": err.Error()}).Error("unable to store outbox event")
+	}
+
+	if len(failures) > 0 {
+		fields := log.Fields{"event_id": outboxEvent.Identifier, "type": outboxEvent.Type, "attempts": outboxEvent.Attempts, "error": strings.Join(failures, "; ")}
+		log.WithFields(eventLogFields).WithFields(fields).Warn("unable to publish event")
+	}
+	return true
+}
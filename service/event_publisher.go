@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/swimresults/athlete-service/model"
+	"os"
+	"sync"
+)
+
+// webhookPublisher queues a delivery for every webhook subscribed to an event.
+type webhookPublisher struct{}
+
+func (webhookPublisher) Name() string {
+	return "webhook"
+}
+
+func (webhookPublisher) Publish(ctx context.Context, event model.Event) error {
+	return queueWebhookDeliveries(ctx, event)
+}
+
+// MemoryPublisher buffers the events in a channel of this process, so tests and embedding applications can receive
+// them without a broker. While the buffer is full publishing fails, the outbox then retries the event later.
+type MemoryPublisher struct {
+	events chan model.Event
+}
+
+func NewMemoryPublisher(size int) *MemoryPublisher {
+	return &MemoryPublisher{events: make(chan model.Event, size)}
+}
+
+func (p *MemoryPublisher) Name() string {
+	return "memory"
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, event model.Event) error {
+	select {
+	case p.events <- event:
+		return nil
+	default:
+		return errors.New("memory publisher buffer is full")
+	}
+}
+
+// Events returns the channel the published events are buffered in.
+func (p *MemoryPublisher) Events() <-chan model.Event {
+	return p.events
+}
+
+// FilePublisher appends every event as a JSON line to a file, which other services can follow.
+type FilePublisher struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFilePublisher(path string) *FilePublisher {
+	return &FilePublisher{path: path}
+}
+
+func (p *FilePublisher) Name() string {
+	return "file:" + p.path
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	file, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
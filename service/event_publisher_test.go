@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher(2)
+
+	events := []model.Event{
+		{Identifier: primitive.NewObjectID(), Type: model.EventAthleteCreated},
+		{Identifier: primitive.NewObjectID(), Type: model.EventTeamUpdated},
+		{Identifier: primitive.NewObjectID(), Type: model.EventCertificateDeleted},
+	}
+
+	tests := []struct {
+		event   model.Event
+		wantErr bool
+	}{
+		{events[0], false},
+		{events[1], false},
+		{events[2], true},
+	}
+	for _, test := range tests {
+		err := publisher.Publish(context.Background(), test.event)
+		if (err != nil) != test.wantErr {
+			t.Errorf("publishing %s: got error %v, want error %t", test.event.Type, err, test.wantErr)
+		}
+	}
+
+	for _, want := range events[:2] {
+		if got := <-publisher.Events(); got.Identifier != want.Identifier {
+			t.Errorf("got event %s, want %s", got.Identifier.Hex(), want.Identifier.Hex())
+		}
+	}
+	if err := publisher.Publish(context.Background(), events[2]); err != nil {
+		t.Errorf("expected room after the events were received, got %v", err)
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	publisher := NewFilePublisher(path)
+
+	events := []model.Event{
+		{Identifier: primitive.NewObjectID(), Type: model.EventAthleteCreated, Meetings: []string{"IESC13"}},
+		{Identifier: primitive.NewObjectID(), Type: model.EventParticipationAdded, Meetings: []string{"IESC14"}},
+	}
+	for _, event := range events {
+		if err := publisher.Publish(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var got []model.Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event model.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, event)
+	}
+
+	if len(got) != len(events) {
+		t.Fatalf("got %d lines, want %d", len(got), len(events))
+	}
+	for i := range events {
+		if got[i].Identifier != events[i].Identifier || got[i].Type != events[i].Type {
+			t.Errorf("line %d: got %s %s, want %s %s", i+1, got[i].Type, got[i].Identifier.Hex(), events[i].Type, events[i].Identifier.Hex())
+		}
+	}
+}
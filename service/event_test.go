@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestEntityChangeEvent(t *testing.T) {
+	athlete := model.Athlete{Name: "Simon Meier", Participation: []string{"IESC13", "IESC14"}}
+	moved := model.Athlete{Name: "Simon Meier", Participation: []string{"IESC14", "IESC15"}}
+	certificate := model.Certificate{Meeting: "IESC13"}
+
+	tests := []struct {
+		name         string
+		action       string
+		entity       string
+		before       interface{}
+		after        interface{}
+		wantType     string
+		wantMeetings []string
+		wantChanged  bool
+	}{
+		{"created", model.AuditActionAdd, "athlete", nil, athlete, model.EventAthleteCreated, []string{"IESC13", "IESC14"}, true},
+		{"deleted", model.AuditActionDelete, "athlete", athlete, nil, model.EventAthleteDeleted, []string{"IESC13", "IESC14"}, true},
+		{"updated with meetings before and after", model.AuditActionUpdate, "athlete", athlete, moved, model.EventAthleteUpdated, []string{"IESC13", "IESC14", "IESC15"}, true},
+		{"unchanged", model.AuditActionUpdate, "athlete", athlete, athlete, model.EventAthleteUpdated, []string{"IESC13", "IESC14"}, false},
+		{"participation", model.AuditActionParticipation, "athlete", athlete, moved, model.EventParticipationAdded, []string{"IESC13", "IESC14", "IESC15"}, true},
+		{"meeting removed", model.AuditActionRemoveMeeting, "athlete", athlete, moved, model.EventParticipationRemoved, []string{"IESC13", "IESC14", "IESC15"}, true},
+		{"certificate of removed meeting", model.AuditActionRemoveMeeting, "certificate", certificate, nil, model.EventCertificateDeleted, []string{"IESC13"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := newEntityChange(context.Background(), test.action, test.entity, primitive.NewObjectID(), test.before, test.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.eventType(); got != test.wantType {
+				t.Errorf("got type %s, want %s", got, test.wantType)
+			}
+			if fmt.Sprint(c.meetings) != fmt.Sprint(test.wantMeetings) {
+				t.Errorf("got meetings %v, want %v", c.meetings, test.wantMeetings)
+			}
+			if got := c.changed(); got != test.wantChanged {
+				t.Errorf("got changed %t, want %t", got, test.wantChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"time"
+)
+
+var importConflictCollection *mongo.Collection
+
+// fields that can be locked against imports, an import keeps their value and records a conflict instead. Matching an
+// import still uses the locked values. The name an athlete or team is shown with can not be locked, for athletes it
+// follows firstname and lastname, teams are matched by it.
+var athleteLockableFields = []string{"firstname", "lastname", "year", "gender", "dsv_id"}
+var teamLockableFields = []string{"country", "dsv_id", "state_id", "address", "contact", "website", "logo_url", "color_set"}
+
+func fieldLockService(database *mongo.Database) {
+	importConflictCollection = database.Collection("import_conflict", documentMOptions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = importConflictCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"entity", 1}, {"entity_id", 1}, {"field", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+}
+
+func LockAthleteFields(ctx context.Context, id primitive.ObjectID, fields []string) (model.Athlete, error) {
+	err := setFieldLocks(ctx, athleteCollection, "athlete", id, fields, athleteLockableFields, true)
+	if err != nil {
+		return model.Athlete{}, err
+	}
+	return GetAthleteById(id)
+}
+
+// UnlockAthleteFields removes the locks of the given fields together with their recorded conflicts.
+func UnlockAthleteFields(ctx context.Context, id primitive.ObjectID, fields []string) (model.Athlete, error) {
+	err := setFieldLocks(ctx, athleteCollection, "athlete", id, fields, athleteLockableFields, false)
+	if err != nil {
+		return model.Athlete{}, err
+	}
+	return GetAthleteById(id)
+}
+
+func LockTeamFields(ctx context.Context, id primitive.ObjectID, fields []string) (model.Team, error) {
+	err := setFieldLocks(ctx, teamCollection, "team", id, fields, teamLockableFields, true)
+	if err != nil {
+		return model.Team{}, err
+	}
+	return GetTeamById(id)
+}
+
+// UnlockTeamFields removes the locks of the given fields together with their recorded conflicts.
+func UnlockTeamFields(ctx context.Context, id primitive.ObjectID, fields []string) (model.Team, error) {
+	err := setFieldLocks(ctx, teamCollection, "team", id, fields, teamLockableFields, false)
+	if err != nil {
+		return model.Team{}, err
+	}
+	return GetTeamById(id)
+}
+
+func setFieldLocks(ctx context.Context, collection *mongo.Collection, entity string, id primitive.ObjectID, fields []string, lockable []string, lock bool) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("no fields given")
+	}
+	for _, field := range fields {
+		if !isLocked(lockable, field) {
+			return fmt.Errorf("field '%s' can not be locked", field)
+		}
+	}
+
+	return withTransaction(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		var before bson.M
+		err := findDocumentById(ctx, collection, id, &before)
+		if err != nil {
+			return err
+		}
+
+		update := bson.D{{"$addToSet", bson.D{{"locked", bson.D{{"$each", fields}}}}}}
+		if !lock {
+			update = bson.D{{"$pull", bson.D{{"locked", bson.D{{"$in", fields}}}}}}
+		}
+
+		_, err = collection.UpdateOne(ctx, bson.D{{"_id", id}}, update)
+		if err != nil {
+			return err
+		}
+
+		if !lock {
+			_, err = importConflictCollection.DeleteMany(ctx, bson.D{{"entity", entity}, {"entity_id", id}, {"field", bson.D{{"$in", fields}}}})
+			if err != nil {
+				return err
+			}
+		}
+
+		var after bson.M
+		err = findDocumentById(ctx, collection, id, &after)
+		if err != nil {
+			return err
+		}
+
+		action := model.AuditActionLock
+		if !lock {
+			action = model.AuditActionUnlock
+		}
+		return recordChange(ctx, action, entity, id, before, after)
+	})
+}
+
+func isLocked(locked []string, field string) bool {
+	for _, l := range locked {
+		if l == field {
+			return true
+		}
+	}
+	return false
+}
+
+// lockConflicts compares the locked fields of the existing entity with the imported one, every field the import
+// brings a different value for is returned as conflict.
+func lockConflicts(existing interface{}, incoming interface{}, locked []string) ([]dto.FieldChangeDto, error) {
+	if len(locked) == 0 {
+		return nil, nil
+	}
+
+	existingFields, err := toBsonFields(existing)
+	if err != nil {
+		return nil, err
+	}
+	incomingFields, err := toBsonFields(incoming)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []dto.FieldChangeDto
+	for _, field := range locked {
+		value, ok := incomingFields[field]
+		if !ok || isEmptyDocument(value) || reflect.DeepEqual(existingFields[field], value) {
+			continue
+		}
+		conflicts = append(conflicts, dto.FieldChangeDto{Field: field, From: existingFields[field], To: value})
+	}
+	return conflicts, nil
+}
+
+func recordImportConflicts(ctx context.Context, entity string, id primitive.ObjectID, conflicts []dto.FieldChangeDto) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	info := provenanceFromContext(ctx)
+	for _, conflict := range conflicts {
+		_, err := importConflictCollection.UpdateOne(ctx,
+			bson.D{{"entity", entity}, {"entity_id", id}, {"field", conflict.Field}},
+			bson.D{{"$set", bson.D{
+				{"locked", conflict.From},
+				{"incoming", conflict.To},
+				{"source", info.source},
+				{"meeting", info.meeting},
+				{"updated_at", time.Now()},
+			}}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+
+		fields := log.Fields{"entity": entity, "entity_id": id, "field": conflict.Field, "incoming": conflict.To}
+		log.WithFields(importLogFields).WithFields(fields).Warn("import conflicts with locked field")
+	}
+	return nil
+}
+
+// GetImportConflicts returns the recorded conflicts, filtered by entity, entity id and meeting if given.
+func GetImportConflicts(entity string, id primitive.ObjectID, meeting string, paging Paging) ([]model.ImportConflict, Page, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), paging.timeout())
+	defer cancel()
+
+	filter := bson.D{}
+	if entity != "" {
+		filter = append(filter, bson.E{Key: "entity", Value: entity})
+	}
+	if !id.IsZero() {
+		filter = append(filter, bson.E{Key: "entity_id", Value: id})
+	}
+	if meeting != "" {
+		filter = append(filter, bson.E{Key: "meeting", Value: meeting})
+	}
+
+	conflicts, page, err := findPage[model.ImportConflict](ctx, importConflictCollection, filter, bson.D{{"updated_at", -1}}, nil, paging, nil)
+	if err != nil {
+		return []model.ImportConflict{}, Page{}, err
+	}
+	return conflicts, page, nil
+}
+
+// isEmptyDocument reports whether an embedded struct was imported without any values, zero structs are not omitted
+// when encoded.
+func isEmptyDocument(value interface{}) bool {
+	switch v := value.(type) {
+	case primitive.D:
+		return len(v) == 0
+	case bson.M:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"github.com/swimresults/athlete-service/model"
+	"testing"
+)
+
+func TestLockConflicts(t *testing.T) {
+	existing := model.Athlete{Name: "Simon Meier", Firstname: "Simon", Lastname: "Meier", Year: 2010, Gender: "M", DsvId: 123444}
+	existingTeam := model.Team{Name: "SV Test", Country: "GER", Address: model.Address{City: "Berlin"}}
+
+	tests := []struct {
+		name     string
+		existing interface{}
+		incoming interface{}
+		locked   []string
+		want     []string
+	}{
+		{
+			name:     "nothing locked",
+			existing: existing,
+			incoming: model.Athlete{Firstname: "Simone", Year: 2011},
+			want:     nil,
+		},
+		{
+			name:     "locked fields with other values",
+			existing: existing,
+			incoming: model.Athlete{Firstname: "Simone", Lastname: "Meier", Year: 2011},
+			locked:   []string{"firstname", "lastname", "year"},
+			want:     []string{"firstname: Simon -> Simone", "year: 2010 -> 2011"},
+		},
+		{
+			name:     "fields the import leaves out",
+			existing: existing,
+			incoming: model.Athlete{Name: "Simon Meier"},
+			locked:   []string{"gender", "dsv_id"},
+			want:     nil,
+		},
+		{
+			name:     "unlocked fields ignored",
+			existing: existing,
+			incoming: model.Athlete{Gender: "W", DsvId: 1},
+			locked:   []string{"dsv_id"},
+			want:     []string{"dsv_id: 123444 -> 1"},
+		},
+		{
+			name:     "empty embedded document",
+			existing: existingTeam,
+			incoming: model.Team{Name: "SV Test", Country: "AUT"},
+			locked:   []string{"country", "address"},
+			want:     []string{"country: GER -> AUT"},
+		},
+		{
+			name:     "value on a field empty before",
+			existing: model.Team{Name: "SV Test"},
+			incoming: model.Team{Name: "SV Test", Website: "https://sv-test.de"},
+			locked:   []string{"website"},
+			want:     []string{"website: <nil> -> https://sv-test.de"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conflicts, err := lockConflicts(test.existing, test.incoming, test.locked)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, conflict := range conflicts {
+				got = append(got, fmt.Sprintf("%s: %v -> %v", conflict.Field, conflict.From, conflict.To))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
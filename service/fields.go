@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidFields = errors.New("given fields are invalid")
+
+var athleteFields = []string{"_id", "name", "firstname", "lastname", "alias", "year", "gender", "dsv_id", "team", "first_meeting", "participation", "locked"}
+var teamFields = []string{"_id", "name", "alias", "country", "dsv_id", "state_id", "address", "contact", "website", "logo_url", "color_set", "first_meeting", "participation", "locked"}
+
+// Fields selects the fields of returned documents, nested fields are separated by dots. No fields select all of them.
+type Fields []string
+
+// ParseFields splits a comma separated list of fields.
+func ParseFields(fields string) Fields {
+	var parsed Fields
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			parsed = append(parsed, field)
+		}
+	}
+	return parsed
+}
+
+// validate checks that the top level of every field is one of the known fields.
+func (f Fields) validate(known []string) error {
+	for _, field := range f {
+		top, _, _ := strings.Cut(field, ".")
+		if !slices.Contains(known, top) {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidFields, field)
+		}
+	}
+	return nil
+}
+
+// includes reports whether the field or any of its nested fields is selected.
+func (f Fields) includes(field string) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, selected := range f {
+		if selected == field || strings.HasPrefix(selected, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// nested returns the selected fields below the given field, all of them if the field itself is selected.
+func (f Fields) nested(field string) Fields {
+	var nested Fields
+	for _, selected := range f {
+		if selected == field {
+			return nil
+		}
+		if sub, found := strings.CutPrefix(selected, field+"."); found {
+			nested = append(nested, sub)
+		}
+	}
+	return nested
+}
+
+// projection returns the projection of the selected fields together with the given keys, which the query relies on,
+// e.g. the sort keys. A field embedded from another collection is replaced by its reference. Without fields it is
+// nil, so the whole documents are returned.
+func (f Fields) projection(embedded map[string]string, keys ...string) bson.D {
+	if len(f) == 0 {
+		return nil
+	}
+
+	projection := bson.D{}
+	add := func(key string) {
+		kept := bson.D{}
+		for _, e := range projection {
+			if e.Key == key || strings.HasPrefix(key, e.Key+".") {
+				return
+			}
+			// a whole document replaces its nested fields, mongo rejects both in one projection
+			if !strings.HasPrefix(e.Key, key+".") {
+				kept = append(kept, e)
+			}
+		}
+		projection = append(kept, bson.E{Key: key, Value: 1})
+	}
+
+	for _, field := range f {
+		top, _, _ := strings.Cut(field, ".")
+		if reference, ok := embedded[top]; ok {
+			add(reference)
+			continue
+		}
+		add(field)
+	}
+	for _, key := range keys {
+		add(key)
+	}
+	return projection
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		want   Fields
+	}{
+		{"empty", "", nil},
+		{"single", "name", Fields{"name"}},
+		{"several", "name,team.name", Fields{"name", "team.name"}},
+		{"spaces and empty entries", " name , ,year,", Fields{"name", "year"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ParseFields(test.fields); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFieldsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields Fields
+		valid  bool
+	}{
+		{"no fields", nil, true},
+		{"known fields", Fields{"name", "year"}, true},
+		{"nested field of known field", Fields{"team.name"}, true},
+		{"unknown field", Fields{"name", "password"}, false},
+		{"unknown top level of nested field", Fields{"club.name"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.fields.validate(athleteFields)
+			if test.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.valid && !errors.Is(err, ErrInvalidFields) {
+				t.Errorf("got %v, want ErrInvalidFields", err)
+			}
+		})
+	}
+}
+
+func TestFieldsIncludes(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields Fields
+		field  string
+		want   bool
+	}{
+		{"no fields select all", nil, "team", true},
+		{"selected field", Fields{"team"}, "team", true},
+		{"nested field selected", Fields{"team.name"}, "team", true},
+		{"other field", Fields{"name"}, "team", false},
+		{"field with same prefix", Fields{"teammate"}, "team", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.fields.includes(test.field); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFieldsNested(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields Fields
+		want   Fields
+	}{
+		{"no fields", nil, nil},
+		{"nested fields", Fields{"name", "team.name", "team.alias"}, Fields{"name", "alias"}},
+		{"whole field selected", Fields{"team.name", "team"}, nil},
+		{"field with same prefix", Fields{"teammate.name"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.fields.nested("team"); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFieldsProjection(t *testing.T) {
+	embedded := map[string]string{"team": "team_id"}
+
+	tests := []struct {
+		name   string
+		fields Fields
+		keys   []string
+		want   bson.D
+	}{
+		{"no fields", nil, []string{"_id"}, nil},
+		{"fields and keys", Fields{"name", "year"}, []string{"lastname", "_id"},
+			bson.D{{"name", 1}, {"year", 1}, {"lastname", 1}, {"_id", 1}}},
+		{"duplicate key", Fields{"lastname"}, []string{"lastname", "_id"},
+			bson.D{{"lastname", 1}, {"_id", 1}}},
+		{"embedded field replaced by reference", Fields{"team.name", "team.alias"}, []string{"_id"},
+			bson.D{{"team_id", 1}, {"_id", 1}}},
+		{"nested field of selected document", Fields{"participation", "participation.meeting"}, nil,
+			bson.D{{"participation", 1}}},
+		{"whole document replaces nested fields", Fields{"participation.meeting", "participation"}, nil,
+			bson.D{{"participation", 1}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.fields.projection(embedded, test.keys...); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
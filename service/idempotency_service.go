@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"time"
+)
+
+var idempotencyCollection *mongo.Collection
+var idempotencyWindow = 24 * time.Hour
+
+// idempotencyPendingLease is how long a pending record is kept without being renewed by its running request. The key
+// of a request that stopped, e.g. by a crash of its instance, can be used again afterwards.
+const idempotencyPendingLease = time.Minute
+
+func idempotencyService(database *mongo.Database) {
+	idempotencyCollection = database.Collection("idempotency")
+
+	if window := os.Getenv("SR_ATHLETE_IDEMPOTENCY_WINDOW"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			log.WithFields(log.Fields{"window": window}).Warn("invalid idempotency window, using default")
+		} else {
+			idempotencyWindow = d
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// expired keys are also ignored on lookup, the index only cleans them up
+	_, err := idempotencyCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"created_at", 1}},
+		Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(int32(idempotencyWindow.Seconds())),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"error": err.Error()}).Warn("unable to create idempotency ttl index")
+	}
+}
+
+// ReserveIdempotencyKey stores a pending record for the key. If the key is already known, the existing record is
+// returned together with true, it is not completed as long as the first request is still running. A pending record
+// whose lease expired is taken over, the request that reserved it is assumed to have stopped.
+func ReserveIdempotencyKey(id string, requestHash string) (model.IdempotencyRecord, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	record := model.IdempotencyRecord{
+		Identifier:   id,
+		RequestHash:  requestHash,
+		CreatedAt:    now,
+		PendingUntil: now.Add(idempotencyPendingLease),
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := idempotencyCollection.InsertOne(ctx, record)
+		if err == nil {
+			return record, false, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return model.IdempotencyRecord{}, false, err
+		}
+
+		var existing model.IdempotencyRecord
+		err = idempotencyCollection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&existing)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+			return model.IdempotencyRecord{}, false, err
+		}
+
+		if existing.CreatedAt.Before(now.Add(-idempotencyWindow)) {
+			_, err = idempotencyCollection.DeleteOne(ctx, bson.D{{"_id", id}, {"created_at", existing.CreatedAt}})
+			if err != nil {
+				return model.IdempotencyRecord{}, false, err
+			}
+			continue
+		}
+
+		if existing.Completed || existing.PendingUntil.After(now) {
+			return existing, true, nil
+		}
+
+		r, err := idempotencyCollection.ReplaceOne(ctx,
+			bson.D{{"_id", id}, {"created_at", existing.CreatedAt}, {"completed", bson.D{{"$ne", true}}}},
+			record,
+		)
+		if err != nil {
+			return model.IdempotencyRecord{}, false, err
+		}
+		if r.MatchedCount == 1 {
+			log.WithFields(log.Fields{"key": id}).Info("idempotency key taken over after its lease expired")
+			return record, false, nil
+		}
+	}
+
+	return model.IdempotencyRecord{}, false, errors.New("unable to reserve idempotency key")
+}
+
+// RenewIdempotencyKey extends the lease of the pending record reserved at createdAt until ctx is done.
+func RenewIdempotencyKey(ctx context.Context, id string, createdAt time.Time) {
+	ticker := time.NewTicker(idempotencyPendingLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		updateCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		_, err := idempotencyCollection.UpdateOne(updateCtx,
+			bson.D{{"_id", id}, {"created_at", createdAt}, {"completed", bson.D{{"$ne", true}}}},
+			bson.D{{"$set", bson.D{{"pending_until", time.Now().Add(idempotencyPendingLease)}}}},
+		)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.WithFields(log.Fields{"key": id, "error": err.Error()}).Warn("unable to renew idempotency key")
+		}
+	}
+}
+
+// CompleteIdempotencyKey stores the response of the request that reserved the key, unless the key was taken over in
+// the meantime.
+func CompleteIdempotencyKey(record model.IdempotencyRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	record.Completed = true
+	record.PendingUntil = time.Time{}
+
+	_, err := idempotencyCollection.ReplaceOne(ctx, bson.D{{"_id", record.Identifier}, {"created_at", record.CreatedAt}}, record)
+	return err
+}
+
+// ReleaseIdempotencyKey removes the key reserved at createdAt, so a failed request can be retried with the same key.
+func ReleaseIdempotencyKey(id string, createdAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := idempotencyCollection.DeleteOne(ctx, bson.D{{"_id", id}, {"created_at", createdAt}})
+	return err
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var importBatchCollection *mongo.Collection
+var importBatchChangeCollection *mongo.Collection
+
+type importBatchKey struct{}
+
+func importBatchService(database *mongo.Database) {
+	importBatchCollection = database.Collection("import_batch")
+	importBatchChangeCollection = database.Collection("import_batch_change")
+
+	ensureIndexes(importBatchChangeCollection,
+		mongo.IndexModel{Keys: bson.D{{"batch_id", 1}, {"added_at", 1}}},
+	)
+}
+
+// WithImportBatch returns a context that makes the imports run with it record their changes for the given batch.
+func WithImportBatch(ctx context.Context, batchId primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, importBatchKey{}, batchId)
+}
+
+func AddImportBatch(meeting string) (model.ImportBatch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	batch := model.ImportBatch{
+		Meeting:   meeting,
+		CreatedAt: time.Now(),
+	}
+
+	r, err := importBatchCollection.InsertOne(ctx, batch)
+	if err != nil {
+		return model.ImportBatch{}, err
+	}
+
+	fields := log.Fields{"batch": batch}
+	log.WithFields(importLogFields).WithFields(fields).Info("import batch added")
+
+	return GetImportBatchById(r.InsertedID.(primitive.ObjectID))
+}
+
+func GetImportBatchById(id primitive.ObjectID) (model.ImportBatch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var batch model.ImportBatch
+	err := importBatchCollection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&batch)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.ImportBatch{}, errors.New("no entry with given id found")
+		}
+		return model.ImportBatch{}, err
+	}
+
+	count, err := importBatchChangeCollection.CountDocuments(ctx, bson.D{{"batch_id", id}})
+	if err != nil {
+		return model.ImportBatch{}, err
+	}
+	batch.Changes = int(count)
+
+	return batch, nil
+}
+
+// recordImportBatchChange stores the current state of an entity for the import batch of the context, if there is one.
+// It has to be called before an existing entity is changed and after a new entity was created.
+func recordImportBatchChange(ctx context.Context, collection *mongo.Collection, entity string, id primitive.ObjectID, created bool) error {
+	batchId, ok := ctx.Value(importBatchKey{}).(primitive.ObjectID)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	change := model.ImportBatchChange{
+		BatchId:  batchId,
+		Entity:   entity,
+		EntityId: id,
+		Created:  created,
+		AddedAt:  time.Now(),
+	}
+
+	if !created {
+		err := collection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&change.Before)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := importBatchChangeCollection.InsertOne(ctx, change)
+	return err
+}
+
+// importRollbackBatchSize bounds the changes undone in one transaction by a rollback, undoing a large import at once
+// would exceed the time MongoDB allows a transaction to run.
+const importRollbackBatchSize = 500
+
+// RollbackImportBatch undoes all changes of a batch in reverse order: created entities are deleted and changed ones are
+// restored to their state before the import. Changes made after the import to those entities are lost. Every restore
+// and delete is recorded as a rollback change. The changes are undone in batches, each in its own transaction, and
+// marked as rolled back, a rollback that failed part way is completed by running it again.
+func RollbackImportBatch(ctx context.Context, id primitive.ObjectID) (dto.ImportBatchRollbackDto, error) {
+	batch, err := GetImportBatchById(id)
+	if err != nil {
+		return dto.ImportBatchRollbackDto{}, err
+	}
+	if !batch.RolledBackAt.IsZero() {
+		return dto.ImportBatchRollbackDto{}, errors.New("import batch was already rolled back")
+	}
+
+	report := dto.ImportBatchRollbackDto{Batch: id}
+	for {
+		var count int
+		err = withTransaction(ctx, func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+
+			opts := options.Find().SetSort(bson.D{{"added_at", -1}, {"_id", -1}}).SetLimit(importRollbackBatchSize)
+			cursor, err := importBatchChangeCollection.Find(ctx, bson.D{{"batch_id", id}, {"rolled_back_at", bson.D{{"$exists", false}}}}, opts)
+			if err != nil {
+				return err
+			}
+
+			var changes []model.ImportBatchChange
+			if err := cursor.All(ctx, &changes); err != nil {
+				return err
+			}
+			count = len(changes)
+
+			batchReport := dto.ImportBatchRollbackDto{}
+			for _, change := range changes {
+				if err := rollbackImportBatchChange(ctx, change, &batchReport); err != nil {
+					return err
+				}
+			}
+			afterCommit(ctx, func() {
+				report.Deleted += batchReport.Deleted
+				report.Restored += batchReport.Restored
+			})
+
+			if count == 0 {
+				return nil
+			}
+			ids := make([]primitive.ObjectID, len(changes))
+			for i, change := range changes {
+				ids[i] = change.Identifier
+			}
+			_, err = importBatchChangeCollection.UpdateMany(ctx, bson.D{{"_id", bson.D{{"$in", ids}}}}, bson.D{{"$set", bson.D{{"rolled_back_at", time.Now()}}}})
+			return err
+		})
+		if err != nil {
+			return dto.ImportBatchRollbackDto{}, err
+		}
+		if count < importRollbackBatchSize {
+			break
+		}
+	}
+
+	updateCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err = importBatchCollection.UpdateOne(updateCtx, bson.D{{"_id", id}}, bson.D{{"$set", bson.D{{"rolled_back_at", time.Now()}}}})
+	if err != nil {
+		return dto.ImportBatchRollbackDto{}, err
+	}
+
+	fields := log.Fields{"batch_id": id, "deleted": report.Deleted, "restored": report.Restored}
+	log.WithFields(importLogFields).WithFields(fields).Info("import batch rolled back")
+
+	return report, nil
+}
+
+// rollbackImportBatchChange deletes the entity created by the change or restores the entity changed by it and counts
+// this in the report.
+func rollbackImportBatchChange(ctx context.Context, change model.ImportBatchChange, report *dto.ImportBatchRollbackDto) error {
+	var collection *mongo.Collection
+	switch change.Entity {
+	case "athlete":
+		collection = athleteCollection
+	case "team":
+		collection = teamCollection
+	case "certificate":
+		collection = certificateCollection
+	default:
+		return nil
+	}
+
+	var current bson.M
+	err := findDocumentById(ctx, collection, change.EntityId, &current)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	exists := err == nil
+
+	switch {
+	case change.Created && !exists:
+		// deleted since the import
+		return nil
+	case change.Created:
+		report.Deleted++
+		_, err = collection.DeleteOne(ctx, bson.D{{"_id", change.EntityId}})
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, model.AuditActionRollback, change.Entity, change.EntityId, current, nil)
+	case exists:
+		report.Restored++
+		return replaceWithChange(ctx, model.AuditActionRollback, collection, change.Entity, change.EntityId, current, change.Before)
+	default:
+		report.Restored++
+		_, err = collection.InsertOne(ctx, change.Before)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, model.AuditActionRollback, change.Entity, change.EntityId, nil, change.Before)
+	}
+}
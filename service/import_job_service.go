@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var importJobCollection *mongo.Collection
+var importJobItemCollection *mongo.Collection
+var importJobQueue = make(chan importJobTask)
+
+// items of a job are handed to the workers in batches of this size
+const importJobBatchSize = 100
+
+// importJobLease is how long a job stays claimed by the instance running it without being renewed, afterwards another
+// instance resumes it.
+const importJobLease = time.Minute
+
+type importJobTask struct {
+	job  *model.ImportJob
+	item model.ImportJobItem
+	done *sync.WaitGroup
+}
+
+func importJobService(database *mongo.Database) {
+	importJobCollection = database.Collection("import_job")
+	importJobItemCollection = database.Collection("import_job_item")
+
+	ensureIndexes(importJobCollection,
+		mongo.IndexModel{Keys: bson.D{{"status", 1}, {"lease_until", 1}}},
+	)
+	ensureIndexes(importJobItemCollection,
+		mongo.IndexModel{Keys: bson.D{{"job_id", 1}, {"index", 1}}, Options: options.Index().SetUnique(true)},
+	)
+
+	workers := 4
+	if w, err := strconv.Atoi(os.Getenv("SR_ATHLETE_IMPORT_WORKERS")); err == nil && w > 0 {
+		workers = w
+	}
+
+	for i := 0; i < workers; i++ {
+		go runImportJobWorker()
+	}
+}
+
+// resumeImportJobs continues the unfinished jobs whose lease expired because the instance running them stopped. Jobs
+// still run by another instance are left alone. It checks again after every lease period.
+func resumeImportJobs() {
+	go func() {
+		for {
+			for {
+				job, err := claimImportJob()
+				if err != nil {
+					if !errors.Is(err, mongo.ErrNoDocuments) {
+						log.WithFields(importLogFields).WithFields(log.Fields{"error": err.Error()}).Error("unable to resume import jobs")
+					}
+					break
+				}
+
+				log.WithFields(importLogFields).WithFields(log.Fields{"job_id": job.Identifier}).Info("resuming import job")
+				go runImportJob(&job)
+			}
+			time.Sleep(importJobLease)
+		}
+	}()
+}
+
+// claimImportJob takes over an unfinished job with an expired lease, the update makes sure only one instance gets it.
+func claimImportJob() (model.ImportJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var job model.ImportJob
+	err := importJobCollection.FindOneAndUpdate(ctx,
+		bson.D{{"status", bson.D{{"$ne", "finished"}}}, {"$or", bson.A{
+			bson.D{{"lease_until", bson.D{{"$lt", now}}}},
+			bson.D{{"lease_until", bson.D{{"$exists", false}}}},
+		}}},
+		bson.D{{"$set", bson.D{{"owner", instanceId}, {"lease_until", now.Add(importJobLease)}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	return job, err
+}
+
+// renewImportJobLease extends the lease of the job until ctx is done. If another instance took the job over in the
+// meantime, lost is called.
+func renewImportJobLease(ctx context.Context, id primitive.ObjectID, lost func()) {
+	ticker := time.NewTicker(importJobLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		updateCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		r, err := importJobCollection.UpdateOne(updateCtx,
+			bson.D{{"_id", id}, {"owner", instanceId}},
+			bson.D{{"$set", bson.D{{"lease_until", time.Now().Add(importJobLease)}}}},
+		)
+		cancel()
+		if err != nil {
+			log.WithFields(importLogFields).WithFields(log.Fields{"job_id": id, "error": err.Error()}).Warn("unable to renew import job lease")
+			continue
+		}
+		if r.MatchedCount == 0 {
+			log.WithFields(importLogFields).WithFields(log.Fields{"job_id": id}).Warn("import job was taken over by another instance")
+			lost()
+			return
+		}
+	}
+}
+
+func AddAthleteImportJob(ctx context.Context, requests []dto.ImportAthleteRequestDto, dryRun bool) (model.ImportJob, error) {
+	var items []model.ImportJobItem
+	for _, request := range requests {
+		athlete := request.Athlete
+		team := request.Athlete.Team
+		items = append(items, model.ImportJobItem{Meeting: request.Meeting, Athlete: &athlete, Team: &team, Source: request.Source})
+	}
+
+	meeting := ""
+	if len(requests) > 0 {
+		meeting = requests[0].Meeting
+	}
+	return addImportJob(ctx, "athlete", meeting, items, dryRun)
+}
+
+func AddTeamImportJob(ctx context.Context, requests []dto.ImportTeamRequestDto, dryRun bool) (model.ImportJob, error) {
+	var items []model.ImportJobItem
+	for _, request := range requests {
+		team := request.Team
+		items = append(items, model.ImportJobItem{Meeting: request.Meeting, Team: &team, Source: request.Source})
+	}
+
+	meeting := ""
+	if len(requests) > 0 {
+		meeting = requests[0].Meeting
+	}
+	return addImportJob(ctx, "team", meeting, items, dryRun)
+}
+
+// AddSpreadsheetImportJob parses the entry list right away, so mapping errors are returned immediately, and imports
+// the rows in the background.
+func AddSpreadsheetImportJob(ctx context.Context, data []byte, mapping dto.SpreadsheetColumnMappingDto, meetId string, dryRun bool) (model.ImportJob, error) {
+	rows, err := parseSpreadsheet(data, mapping)
+	if err != nil {
+		return model.ImportJob{}, err
+	}
+
+	var items []model.ImportJobItem
+	for _, row := range rows {
+		item := model.ImportJobItem{Row: row.row, Meeting: meetId, Skipped: row.skipped, Error: row.err, Source: model.SourceSpreadsheet}
+		if !row.skipped && row.err == "" {
+			athlete := row.athlete
+			team := row.athlete.Team
+			item.Athlete = &athlete
+			item.Team = &team
+		}
+		items = append(items, item)
+	}
+
+	return addImportJob(ctx, "spreadsheet", meetId, items, dryRun)
+}
+
+func addImportJob(ctx context.Context, kind string, meeting string, items []model.ImportJobItem, dryRun bool) (model.ImportJob, error) {
+	job := model.ImportJob{
+		Identifier: primitive.NewObjectID(),
+		Kind:       kind,
+		Meeting:    meeting,
+		DryRun:     dryRun,
+		Status:     "queued",
+		Owner:      instanceId,
+		LeaseUntil: time.Now().Add(importJobLease),
+		Total:      len(items),
+		CreatedAt:  time.Now(),
+	}
+	if batchId, ok := ctx.Value(importBatchKey{}).(primitive.ObjectID); ok {
+		job.Batch = batchId
+	}
+	audit := auditFromContext(ctx)
+	job.Actor = audit.actor
+	job.Endpoint = audit.endpoint
+
+	var documents []interface{}
+	for i := range items {
+		items[i].JobId = job.Identifier
+		items[i].Index = i
+		items[i].Result = model.ImportJobResult{Index: i, Row: items[i].Row}
+		documents = append(documents, items[i])
+	}
+
+	insertCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// the items are stored before the job, so a job is never started with only part of its items
+	if len(documents) > 0 {
+		if _, err := importJobItemCollection.InsertMany(insertCtx, documents); err != nil {
+			_, _ = importJobItemCollection.DeleteMany(insertCtx, bson.D{{"job_id", job.Identifier}})
+			return model.ImportJob{}, err
+		}
+	}
+
+	_, err := importJobCollection.InsertOne(insertCtx, job)
+	if err != nil {
+		_, _ = importJobItemCollection.DeleteMany(insertCtx, bson.D{{"job_id", job.Identifier}})
+		return model.ImportJob{}, err
+	}
+
+	fields := log.Fields{"job_id": job.Identifier, "kind": kind, "total": job.Total}
+	log.WithFields(importLogFields).WithFields(fields).Info("import job added")
+
+	go runImportJob(&job)
+
+	return GetImportJobById(job.Identifier)
+}
+
+// GetImportJobById returns the job with the results of all of its items.
+func GetImportJobById(id primitive.ObjectID) (model.ImportJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var job model.ImportJob
+	err := importJobCollection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.ImportJob{}, errors.New("no entry with given id found")
+		}
+		return model.ImportJob{}, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"index", 1}}).SetProjection(bson.D{{"result", 1}})
+	cursor, err := importJobItemCollection.Find(ctx, bson.D{{"job_id", id}}, opts)
+	if err != nil {
+		return model.ImportJob{}, err
+	}
+
+	var items []model.ImportJobItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return model.ImportJob{}, err
+	}
+	for _, item := range items {
+		job.Results = append(job.Results, item.Result)
+	}
+
+	return job, nil
+}
+
+// nextImportJobItems returns the next unprocessed items of the job after the given index.
+func nextImportJobItems(jobId primitive.ObjectID, after int) ([]model.ImportJobItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"job_id", jobId}, {"index", bson.D{{"$gt", after}}}, {"result.status", bson.D{{"$exists", false}}}}
+	opts := options.Find().SetSort(bson.D{{"index", 1}}).SetLimit(importJobBatchSize)
+	cursor, err := importJobItemCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []model.ImportJobItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// runImportJob hands all unprocessed items of the job to the workers and marks the job finished once they are done. The
+// job has to be claimed by this instance, its lease is renewed while it runs and it stops if the lease is lost.
+func runImportJob(job *model.ImportJob) {
+	running, stop := context.WithCancel(context.Background())
+	defer stop()
+	go renewImportJobLease(running, job.Identifier, stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	_, err := importJobCollection.UpdateOne(ctx, bson.D{{"_id", job.Identifier}, {"owner", instanceId}}, bson.D{{"$set", bson.D{{"status", "running"}, {"started_at", time.Now()}}}})
+	cancel()
+	if err != nil {
+		log.WithFields(importLogFields).WithFields(log.Fields{"job_id": job.Identifier, "error": err.Error()}).Error("unable to start import job")
+		return
+	}
+
+	var done sync.WaitGroup
+	after := -1
+	for running.Err() == nil {
+		items, err := nextImportJobItems(job.Identifier, after)
+		if err != nil {
+			done.Wait()
+			log.WithFields(importLogFields).WithFields(log.Fields{"job_id": job.Identifier, "error": err.Error()}).Error("unable to read import job items")
+			return
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		for _, item := range items {
+			done.Add(1)
+			importJobQueue <- importJobTask{job: job, item: item, done: &done}
+		}
+		after = items[len(items)-1].Index
+	}
+	done.Wait()
+	if running.Err() != nil {
+		return
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = importJobCollection.UpdateOne(ctx, bson.D{{"_id", job.Identifier}, {"owner", instanceId}}, bson.D{{"$set", bson.D{{"status", "finished"}, {"finished_at", time.Now()}}}})
+	if err != nil {
+		log.WithFields(importLogFields).WithFields(log.Fields{"job_id": job.Identifier, "error": err.Error()}).Error("unable to finish import job")
+		return
+	}
+
+	log.WithFields(importLogFields).WithFields(log.Fields{"job_id": job.Identifier}).Info("import job finished")
+}
+
+func runImportJobWorker() {
+	for task := range importJobQueue {
+		result := recoverImportJobItem(task.job, task.item)
+
+		counter := result.Status
+		if counter == "" {
+			counter = dto.ImportStatusFailed
+		}
+
+		// the counters are only incremented by the first result stored for an item
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		r, err := importJobItemCollection.UpdateOne(ctx,
+			bson.D{{"_id", task.item.Identifier}, {"result.status", bson.D{{"$exists", false}}}},
+			bson.D{{"$set", bson.D{{"result", result}}}},
+		)
+		if err == nil && r.ModifiedCount > 0 {
+			_, err = importJobCollection.UpdateOne(ctx, bson.D{{"_id", task.job.Identifier}}, bson.D{{"$inc", bson.D{{"processed", 1}, {counter, 1}}}})
+		}
+		cancel()
+		if err != nil {
+			log.WithFields(importLogFields).WithFields(log.Fields{"job_id": task.job.Identifier, "index": task.item.Index, "error": err.Error()}).Error("unable to store import job result")
+		}
+
+		task.done.Done()
+	}
+}
+
+// recoverImportJobItem processes the item and turns a panic into a failed result, so a single item cannot stop the
+// service.
+func recoverImportJobItem(job *model.ImportJob, item model.ImportJobItem) (result model.ImportJobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			fields := log.Fields{"job_id": item.JobId, "index": item.Index, "panic": r, "stack": string(debug.Stack())}
+			log.WithFields(importLogFields).WithFields(fields).Error("import job item panicked")
+
+			result = model.ImportJobResult{Index: item.Index, Row: item.Row, Status: dto.ImportStatusFailed, Error: fmt.Sprintf("panic: %v", r)}
+		}
+	}()
+
+	return processImportJobItem(job, item)
+}
+
+func processImportJobItem(job *model.ImportJob, item model.ImportJobItem) model.ImportJobResult {
+	result := model.ImportJobResult{Index: item.Index, Row: item.Row}
+
+	fail := func(err error) model.ImportJobResult {
+		result.Status = dto.ImportStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	if item.Skipped {
+		result.Status = dto.ImportStatusSkipped
+		return result
+	}
+	if item.Error != "" {
+		return fail(errors.New(item.Error))
+	}
+	if item.Meeting == "" {
+		return fail(errors.New("given meeting is empty"))
+	}
+
+	ctx := WithAuditActor(importSourceContext(context.Background(), item.Source), job.Actor, job.Endpoint)
+	if !job.Batch.IsZero() {
+		ctx = WithImportBatch(ctx, job.Batch)
+	}
+
+	var created bool
+	var err error
+
+	team := item.Team
+	if team != nil && (job.Kind == "team" || job.Kind == "spreadsheet") {
+		if job.DryRun {
+			var plan dto.ImportPlanDto
+			plan, err = PlanTeamImport(*team, item.Meeting)
+			if plan.Team != nil {
+				team = plan.Team
+			}
+			created = plan.Action == dto.ImportActionCreate
+		} else {
+			var imported model.Team
+			imported, created, err = ImportTeam(ctx, *team, item.Meeting)
+			team = &imported
+		}
+		if err != nil {
+			return fail(err)
+		}
+		result.EntityId = team.Identifier
+	}
+
+	if item.Athlete != nil {
+		athlete := *item.Athlete
+		if team != nil {
+			athlete.Team = *team
+		}
+
+		if job.DryRun {
+			var plan dto.ImportPlanDto
+			plan, err = PlanAthleteImport(athlete, item.Meeting)
+			if plan.Athlete != nil {
+				result.EntityId = plan.Athlete.Identifier
+			}
+			created = plan.Action == dto.ImportActionCreate
+		} else {
+			var imported *model.Athlete
+			imported, created, err = ImportAthlete(ctx, athlete, item.Meeting)
+			if imported != nil {
+				result.EntityId = imported.Identifier
+			}
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if created {
+		result.Status = dto.ImportStatusCreated
+	} else {
+		result.Status = dto.ImportStatusMatched
+	}
+	return result
+}
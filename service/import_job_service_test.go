@@ -0,0 +1,63 @@
+package service
+
+import (
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+)
+
+func TestRecoverImportJobItem(t *testing.T) {
+	job := &model.ImportJob{Identifier: primitive.NewObjectID(), Kind: "athlete"}
+
+	tests := []struct {
+		name      string
+		job       *model.ImportJob
+		item      model.ImportJobItem
+		status    string
+		errPrefix string
+	}{
+		{
+			name:   "skipped",
+			job:    job,
+			item:   model.ImportJobItem{Index: 3, Row: 5, Skipped: true},
+			status: dto.ImportStatusSkipped,
+		},
+		{
+			name:      "invalid row",
+			job:       job,
+			item:      model.ImportJobItem{Index: 1, Row: 2, Meeting: "TEST01", Error: "invalid year 'x'"},
+			status:    dto.ImportStatusFailed,
+			errPrefix: "invalid year 'x'",
+		},
+		{
+			name:      "without meeting",
+			job:       job,
+			item:      model.ImportJobItem{Athlete: &model.Athlete{Name: "Meier, Simon"}},
+			status:    dto.ImportStatusFailed,
+			errPrefix: "given meeting is empty",
+		},
+		{
+			name:      "panic",
+			item:      model.ImportJobItem{Index: 4, Row: 6, Meeting: "TEST01", Athlete: &model.Athlete{Name: "Meier, Simon"}},
+			status:    dto.ImportStatusFailed,
+			errPrefix: "panic: ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := recoverImportJobItem(test.job, test.item)
+			if result.Status != test.status {
+				t.Errorf("status = %q, want %q", result.Status, test.status)
+			}
+			if !strings.HasPrefix(result.Error, test.errPrefix) {
+				t.Errorf("error = %q, want prefix %q", result.Error, test.errPrefix)
+			}
+			if result.Index != test.item.Index || result.Row != test.item.Row {
+				t.Errorf("result of index %d row %d, want index %d row %d", result.Index, result.Row, test.item.Index, test.item.Row)
+			}
+		})
+	}
+}
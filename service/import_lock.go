@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sort"
+	"sync"
+	"time"
+)
+
+var importLockCollection *mongo.Collection
+
+// importLockLease is how long a lock stored in the database is held at most, a lock of a stopped instance is taken
+// over afterwards.
+const importLockLease = time.Minute
+
+// importLockTimeout is how long an import waits for a lock held by another instance.
+const importLockTimeout = 30 * time.Second
+
+type importLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+var importLocksMutex sync.Mutex
+var importLocks = map[string]*importLock{}
+
+func importLockService(database *mongo.Database) {
+	importLockCollection = database.Collection("import_lock")
+
+	ensureIndexes(importLockCollection,
+		mongo.IndexModel{Keys: bson.D{{"expires_at", 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	)
+}
+
+// lockImport locks the given keys for the running import, concurrent imports of the same athlete or team wait for each
+// other instead of both creating a new entry. Each key is locked within the instance first and then in the database,
+// so imports running on different instances wait for each other as well. The keys are locked in order to avoid
+// deadlocks. The returned function releases all keys.
+func lockImport(keys ...string) (func(), error) {
+	keys = append([]string{}, keys...)
+	sort.Strings(keys)
+
+	var locked []string
+	unlock := func() {
+		importLocksMutex.Lock()
+		defer importLocksMutex.Unlock()
+
+		for i := len(locked) - 1; i >= 0; i-- {
+			lock := importLocks[locked[i]]
+			lock.mutex.Unlock()
+			lock.refs--
+			if lock.refs == 0 {
+				delete(importLocks, locked[i])
+			}
+		}
+	}
+
+	for i, key := range keys {
+		if i > 0 && keys[i-1] == key {
+			continue
+		}
+
+		importLocksMutex.Lock()
+		lock, ok := importLocks[key]
+		if !ok {
+			lock = &importLock{}
+			importLocks[key] = lock
+		}
+		lock.refs++
+		importLocksMutex.Unlock()
+
+		lock.mutex.Lock()
+		locked = append(locked, key)
+	}
+
+	var stored []string
+	release := func() {
+		if len(stored) > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			_, _ = importLockCollection.DeleteMany(ctx, bson.D{{"_id", bson.D{{"$in", stored}}}, {"owner", instanceId}})
+		}
+		unlock()
+	}
+
+	for _, key := range locked {
+		if err := storeImportLock(key); err != nil {
+			release()
+			return nil, err
+		}
+		stored = append(stored, key)
+	}
+
+	return release, nil
+}
+
+// storeImportLock takes the lock of the key in the database, waiting while another instance holds it. A lock whose
+// lease expired is taken over, otherwise the upsert fails on the existing key.
+func storeImportLock(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), importLockTimeout)
+	defer cancel()
+
+	for {
+		now := time.Now()
+		_, err := importLockCollection.UpdateOne(ctx,
+			bson.D{{"_id", key}, {"expires_at", bson.D{{"$lt", now}}}},
+			bson.D{{"$set", bson.D{{"owner", instanceId}, {"expires_at", now.Add(importLockLease)}}}},
+			options.Update().SetUpsert(true),
+		)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"strconv"
+	"strings"
+)
+
+var importLogFields = log.Fields{"sr_service": "import"}
+
+// ImportSpreadsheet imports an entry list given as CSV or XLSX file row by row using ImportTeam and ImportAthlete.
+// On a dry run the rows are only planned against the existing athletes and teams, nothing is written.
+func ImportSpreadsheet(ctx context.Context, data []byte, mapping dto.SpreadsheetColumnMappingDto, meetId string, dryRun bool) (dto.SpreadsheetImportResponseDto, error) {
+	response := dto.SpreadsheetImportResponseDto{Meeting: meetId, DryRun: dryRun, Rows: []dto.SpreadsheetRowResultDto{}}
+
+	rows, err := parseSpreadsheet(data, mapping)
+	if err != nil {
+		return response, err
+	}
+
+	ctx = WithProvenanceSource(ctx, model.SourceSpreadsheet)
+
+	teams := map[string]*model.Team{}
+	teamPlans := map[string]*dto.ImportPlanDto{}
+
+	for _, row := range rows {
+		result := dto.SpreadsheetRowResultDto{Row: row.row}
+
+		if row.skipped {
+			result.Status = dto.ImportStatusSkipped
+			response.Skipped++
+			response.Rows = append(response.Rows, result)
+			continue
+		}
+
+		fail := func(message string) {
+			result.Status = dto.ImportStatusFailed
+			result.Message = message
+			response.Failed++
+			response.Rows = append(response.Rows, result)
+		}
+
+		if row.err != "" {
+			fail(row.err)
+			continue
+		}
+
+		club := row.athlete.Team.Name
+		team, ok := teams[club]
+		if !ok {
+			team, teamPlans[club], err = importSpreadsheetTeam(ctx, club, meetId, dryRun)
+			if err != nil {
+				fail(err.Error())
+				continue
+			}
+			teams[club] = team
+		}
+		result.Team = team
+		result.TeamPlan = teamPlans[club]
+
+		athlete := row.athlete
+		if team != nil {
+			athlete.Team = *team
+		}
+
+		var created bool
+		var imported *model.Athlete
+		if dryRun {
+			var plan dto.ImportPlanDto
+			plan, err = PlanAthleteImport(athlete, meetId)
+			result.Plan = &plan
+			imported = plan.Athlete
+			created = plan.Action == dto.ImportActionCreate
+		} else {
+			imported, created, err = ImportAthlete(ctx, athlete, meetId)
+		}
+		if err != nil {
+			result.Plan = nil
+			fail(err.Error())
+			continue
+		}
+
+		result.Athlete = imported
+		if created {
+			result.Status = dto.ImportStatusCreated
+			response.Created++
+		} else {
+			result.Status = dto.ImportStatusMatched
+			response.Matched++
+		}
+		response.Rows = append(response.Rows, result)
+	}
+
+	fields := log.Fields{"meet_id": meetId, "dry_run": dryRun, "created": response.Created, "matched": response.Matched, "failed": response.Failed}
+	log.WithFields(importLogFields).WithFields(fields).Info("spreadsheet imported")
+
+	return response, nil
+}
+
+type spreadsheetRow struct {
+	row     int
+	athlete model.Athlete
+	skipped bool
+	err     string
+}
+
+// parseSpreadsheet reads the athletes of an entry list using the column mapping, the club is set as team name.
+// Empty rows are marked as skipped, rows with invalid values carry an error.
+func parseSpreadsheet(data []byte, mapping dto.SpreadsheetColumnMappingDto) ([]spreadsheetRow, error) {
+	rows, err := readSpreadsheet(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var header []string
+	start := 0
+	if mapping.Header && len(rows) > 0 {
+		header = rows[0].cells
+		start = 1
+	}
+
+	nameCol := resolveColumn(mapping.Name, header)
+	firstnameCol := resolveColumn(mapping.Firstname, header)
+	lastnameCol := resolveColumn(mapping.Lastname, header)
+	yearCol := resolveColumn(mapping.Year, header)
+	genderCol := resolveColumn(mapping.Gender, header)
+	clubCol := resolveColumn(mapping.Club, header)
+	dsvIdCol := resolveColumn(mapping.DsvId, header)
+
+	if nameCol < 0 && (firstnameCol < 0 || lastnameCol < 0) {
+		return nil, fmt.Errorf("no name column or firstname and lastname columns given in mapping")
+	}
+	if yearCol < 0 {
+		return nil, fmt.Errorf("no year column given in mapping")
+	}
+	if clubCol < 0 {
+		return nil, fmt.Errorf("no club column given in mapping")
+	}
+
+	var parsed []spreadsheetRow
+	for i := start; i < len(rows); i++ {
+		row := rows[i].cells
+		cell := func(column int) string {
+			if column < 0 || column >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[column])
+		}
+
+		name := cell(nameCol)
+		if name == "" && cell(lastnameCol) != "" {
+			name = cell(lastnameCol) + ", " + cell(firstnameCol)
+		}
+		club := cell(clubCol)
+		yearValue := cell(yearCol)
+
+		result := spreadsheetRow{row: rows[i].number}
+
+		if name == "" && club == "" && yearValue == "" {
+			result.skipped = true
+			parsed = append(parsed, result)
+			continue
+		}
+
+		if name == "" {
+			result.err = "no name given"
+			parsed = append(parsed, result)
+			continue
+		}
+		if club == "" {
+			result.err = "no club given"
+			parsed = append(parsed, result)
+			continue
+		}
+		year, err := strconv.Atoi(yearValue)
+		if err != nil {
+			result.err = fmt.Sprintf("invalid year '%s'", yearValue)
+			parsed = append(parsed, result)
+			continue
+		}
+		dsvId := 0
+		if v := cell(dsvIdCol); v != "" {
+			dsvId, err = strconv.Atoi(v)
+			if err != nil {
+				result.err = fmt.Sprintf("invalid dsv_id '%s'", v)
+				parsed = append(parsed, result)
+				continue
+			}
+		}
+
+		result.athlete = model.Athlete{
+			Name:   name,
+			Year:   year,
+			Gender: cell(genderCol),
+			DsvId:  dsvId,
+			Team:   model.Team{Name: club},
+		}
+		parsed = append(parsed, result)
+	}
+
+	return parsed, nil
+}
+
+// importSpreadsheetTeam imports the team of a row, on a dry run only the plan is returned together with the existing
+// team or nil for a team that would be created.
+func importSpreadsheetTeam(ctx context.Context, club string, meetId string, dryRun bool) (*model.Team, *dto.ImportPlanDto, error) {
+	if dryRun {
+		plan, err := PlanTeamImport(model.Team{Name: club}, meetId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if plan.Action == dto.ImportActionCreate {
+			return nil, &plan, nil
+		}
+		return plan.Team, &plan, nil
+	}
+
+	team, _, err := ImportTeam(ctx, model.Team{Name: club}, meetId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &team, nil, nil
+}
+
+// resolveColumn finds the 0-based column for a mapping entry given as header caption, 1-based index or column letter.
+func resolveColumn(column string, header []string) int {
+	column = strings.TrimSpace(column)
+	if column == "" {
+		return -1
+	}
+
+	for i, caption := range header {
+		if strings.EqualFold(strings.TrimSpace(caption), column) {
+			return i
+		}
+	}
+
+	if index, err := strconv.Atoi(column); err == nil {
+		if index < 1 {
+			return -1
+		}
+		return index - 1
+	}
+
+	return columnIndex(column)
+}
+
+// importSummaryFields counts the results of a bulk import by status for its log entry.
+func importSummaryFields(dryRun bool, statuses []string) log.Fields {
+	fields := log.Fields{
+		"amount":                len(statuses),
+		"dry_run":               dryRun,
+		dto.ImportStatusCreated: 0,
+		dto.ImportStatusMatched: 0,
+		dto.ImportStatusFailed:  0,
+	}
+	for _, status := range statuses {
+		if count, ok := fields[status].(int); ok {
+			fields[status] = count + 1
+		}
+	}
+	return fields
+}
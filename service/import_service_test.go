@@ -0,0 +1,41 @@
+package service
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
+	"reflect"
+	"testing"
+)
+
+func TestImportSummaryFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		dryRun   bool
+		statuses []string
+		want     log.Fields
+	}{
+		{
+			name: "empty",
+			want: log.Fields{"amount": 0, "dry_run": false, "created": 0, "matched": 0, "failed": 0},
+		},
+		{
+			name:     "counted by status",
+			dryRun:   true,
+			statuses: []string{dto.ImportStatusCreated, dto.ImportStatusMatched, dto.ImportStatusCreated, dto.ImportStatusFailed},
+			want:     log.Fields{"amount": 4, "dry_run": true, "created": 2, "matched": 1, "failed": 1},
+		},
+		{
+			name:     "skipped only in amount",
+			statuses: []string{dto.ImportStatusSkipped, dto.ImportStatusCreated},
+			want:     log.Fields{"amount": 2, "dry_run": false, "created": 1, "matched": 0, "failed": 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := importSummaryFields(test.dryRun, test.statuses); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
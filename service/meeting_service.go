@@ -0,0 +1,649 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"github.com/swimresults/service-core/misc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var meetingRenameCollection *mongo.Collection
+
+func meetingService(database *mongo.Database) {
+	meetingRenameCollection = database.Collection("meeting_rename")
+}
+
+// meetingBatchSize bounds the entities rewritten in one transaction by a meeting removal or rename, rewriting all
+// entries of a large meeting at once would exceed the time MongoDB allows a transaction to run.
+const meetingBatchSize = 500
+
+// RemoveMeeting removes the meeting from the participation of all athletes and teams. If it was their first meeting,
+// their first remaining participation becomes the first meeting. Certificates of the meeting are kept, deleted or
+// reassigned to the target meeting. On a dry run only the report is returned. The entities are changed in batches, each
+// in its own transaction, a removal that failed part way is completed by running it again.
+func RemoveMeeting(ctx context.Context, meeting string, certificates string, target string, dryRun bool) (dto.MeetingRemovalDto, error) {
+	if meeting == "" {
+		return dto.MeetingRemovalDto{}, errors.New("given meeting is empty")
+	}
+	switch certificates {
+	case "", dto.MeetingCertificatesKeep, dto.MeetingCertificatesDelete:
+	case dto.MeetingCertificatesReassign:
+		if target == "" || target == meeting {
+			return dto.MeetingRemovalDto{}, errors.New("no target meeting for the certificates given")
+		}
+	default:
+		return dto.MeetingRemovalDto{}, errors.New("certificates has to be one of keep, delete and reassign")
+	}
+
+	// the removal is recorded as a write of the caller, the removed meeting must not show up as the last writer
+	ctx = withAuditAction(withProvenanceMeeting(ctx, ""), model.AuditActionRemoveMeeting)
+
+	report := dto.MeetingRemovalDto{Meeting: meeting, DryRun: dryRun}
+	filter := bson.D{{"$or", bson.A{bson.D{{"participation", meeting}}, bson.D{{"first_meeting", meeting}}}}}
+
+	err := inMeetingBatches(ctx, athleteCollection, filter, func(ctx context.Context, athletes []model.Athlete) error {
+		batch := dto.MeetingRemovalDto{}
+		var rewrites []meetingRewrite
+		for _, athlete := range athletes {
+			before := athlete
+			athlete.Participation, athlete.FirstMeeting = removeParticipation(athlete.Participation, athlete.FirstMeeting, meeting)
+			countMeetingRemoval(&batch, before.FirstMeeting, athlete.FirstMeeting, athlete.Participation)
+			batch.Athletes++
+			rewrites = append(rewrites, meetingRewrite{id: athlete.Identifier, before: before, after: athlete})
+		}
+		afterCommit(ctx, func() { addMeetingRemoval(&report, batch) })
+
+		if dryRun {
+			return nil
+		}
+		return applyMeetingRewrites(ctx, model.AuditActionRemoveMeeting, athleteCollection, "athlete", meeting, rewrites)
+	})
+	if err != nil {
+		return dto.MeetingRemovalDto{}, err
+	}
+
+	err = inMeetingBatches(ctx, teamCollection, filter, func(ctx context.Context, teams []model.Team) error {
+		batch := dto.MeetingRemovalDto{}
+		var rewrites []meetingRewrite
+		for _, team := range teams {
+			before := team
+			team.Participation, team.FirstMeeting = removeParticipation(team.Participation, team.FirstMeeting, meeting)
+			countMeetingRemoval(&batch, before.FirstMeeting, team.FirstMeeting, team.Participation)
+			batch.Teams++
+			rewrites = append(rewrites, meetingRewrite{id: team.Identifier, before: before, after: team})
+		}
+		afterCommit(ctx, func() { addMeetingRemoval(&report, batch) })
+
+		if dryRun {
+			return nil
+		}
+		return applyMeetingRewrites(ctx, model.AuditActionRemoveMeeting, teamCollection, "team", meeting, rewrites)
+	})
+	if err != nil {
+		return dto.MeetingRemovalDto{}, err
+	}
+
+	if certificates == dto.MeetingCertificatesDelete || certificates == dto.MeetingCertificatesReassign {
+		err = inMeetingBatches(ctx, certificateCollection, bson.D{{"meeting", meeting}}, func(ctx context.Context, certs []model.Certificate) error {
+			var rewrites []meetingRewrite
+			for _, certificate := range certs {
+				rewrite := meetingRewrite{id: certificate.Identifier, before: certificate}
+				if certificates == dto.MeetingCertificatesReassign {
+					certificate.Meeting = target
+					certificate.UpdatedAt = time.Now()
+					rewrite.after = certificate
+				}
+				rewrites = append(rewrites, rewrite)
+			}
+			afterCommit(ctx, func() {
+				if certificates == dto.MeetingCertificatesReassign {
+					report.CertificatesReassigned += len(certs)
+				} else {
+					report.CertificatesDeleted += len(certs)
+				}
+			})
+
+			if dryRun {
+				return nil
+			}
+			return applyMeetingRewrites(ctx, model.AuditActionRemoveMeeting, certificateCollection, "certificate", meeting, rewrites)
+		})
+	} else {
+		countCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		var kept int64
+		kept, err = certificateCollection.CountDocuments(countCtx, bson.D{{"meeting", meeting}})
+		cancel()
+		report.CertificatesKept = int(kept)
+	}
+	if err != nil {
+		return dto.MeetingRemovalDto{}, err
+	}
+
+	if !dryRun {
+		fields := log.Fields{"meeting": meeting, "athletes": report.Athletes, "teams": report.Teams, "certificates": certificates}
+		log.WithFields(fields).Info("meeting removed")
+	}
+	return report, nil
+}
+
+// RenameMeeting replaces the meeting id in the participation and first meeting of all athletes and teams and in all
+// certificates. If an entity already takes part in the new meeting, both participations are merged. The entities are
+// changed in batches, each in its own transaction, so the rename is not atomic: until it is completed, readers see a
+// part of the entities under the old and a part under the new id. The progress is tracked in a MeetingRename, a rename
+// that failed part way is resumed by running it again. Renaming the meeting to another id is rejected until then.
+func RenameMeeting(ctx context.Context, from string, to string, dryRun bool) (dto.MeetingRenameDto, error) {
+	if from == "" || to == "" {
+		return dto.MeetingRenameDto{}, errors.New("given meeting is empty")
+	}
+	if from == to {
+		return dto.MeetingRenameDto{}, errors.New("new meeting id is the same as the old one")
+	}
+
+	ctx = withAuditAction(withProvenanceMeeting(ctx, to), model.AuditActionRenameMeeting)
+
+	report := dto.MeetingRenameDto{From: from, To: to, DryRun: dryRun}
+	filter := bson.D{{"$or", bson.A{bson.D{{"participation", from}}, bson.D{{"first_meeting", from}}}}}
+
+	if !dryRun {
+		resumed, err := startMeetingRename(from, to)
+		if err != nil {
+			return dto.MeetingRenameDto{}, err
+		}
+		report.Resumed = resumed
+	}
+
+	err := inMeetingBatches(ctx, athleteCollection, filter, func(ctx context.Context, athletes []model.Athlete) error {
+		var rewrites []meetingRewrite
+		for _, athlete := range athletes {
+			before := athlete
+			athlete.Participation, athlete.FirstMeeting = renameParticipation(athlete.Participation, athlete.FirstMeeting, from, to)
+			rewrites = append(rewrites, meetingRewrite{id: athlete.Identifier, before: before, after: athlete})
+		}
+		afterCommit(ctx, func() { report.Athletes += len(athletes) })
+
+		if dryRun {
+			return nil
+		}
+		return applyMeetingRewrites(ctx, model.AuditActionRenameMeeting, athleteCollection, "athlete", to, rewrites)
+	})
+	if err != nil {
+		return dto.MeetingRenameDto{}, err
+	}
+
+	err = inMeetingBatches(ctx, teamCollection, filter, func(ctx context.Context, teams []model.Team) error {
+		var rewrites []meetingRewrite
+		for _, team := range teams {
+			before := team
+			team.Participation, team.FirstMeeting = renameParticipation(team.Participation, team.FirstMeeting, from, to)
+			rewrites = append(rewrites, meetingRewrite{id: team.Identifier, before: before, after: team})
+		}
+		afterCommit(ctx, func() { report.Teams += len(teams) })
+
+		if dryRun {
+			return nil
+		}
+		return applyMeetingRewrites(ctx, model.AuditActionRenameMeeting, teamCollection, "team", to, rewrites)
+	})
+	if err != nil {
+		return dto.MeetingRenameDto{}, err
+	}
+
+	err = inMeetingBatches(ctx, certificateCollection, bson.D{{"meeting", from}}, func(ctx context.Context, certificates []model.Certificate) error {
+		var rewrites []meetingRewrite
+		for _, certificate := range certificates {
+			before := certificate
+			certificate.Meeting = to
+			rewrites = append(rewrites, meetingRewrite{id: certificate.Identifier, before: before, after: certificate})
+		}
+		afterCommit(ctx, func() { report.Certificates += len(certificates) })
+
+		if dryRun {
+			return nil
+		}
+		return applyMeetingRewrites(ctx, model.AuditActionRenameMeeting, certificateCollection, "certificate", to, rewrites)
+	})
+	if err != nil {
+		return dto.MeetingRenameDto{}, err
+	}
+
+	if !dryRun {
+		if err := completeMeetingRename(from, to); err != nil {
+			return dto.MeetingRenameDto{}, err
+		}
+
+		fields := log.Fields{"from": from, "to": to, "resumed": report.Resumed, "athletes": report.Athletes, "teams": report.Teams, "certificates": report.Certificates}
+		log.WithFields(fields).Info("meeting renamed")
+	}
+	return report, nil
+}
+
+// GetMeetingRename returns the progress of the last rename of the given meeting.
+func GetMeetingRename(from string) (model.MeetingRename, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var rename model.MeetingRename
+	err := meetingRenameCollection.FindOne(ctx, bson.D{{"_id", from}}).Decode(&rename)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.MeetingRename{}, ErrNotFound
+	}
+	return rename, err
+}
+
+// startMeetingRename records the start of a rename and reports whether an incomplete rename to the same meeting is
+// resumed. An incomplete rename to another meeting keeps its record, the upsert then fails on the existing id.
+func startMeetingRename(from string, to string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var previous model.MeetingRename
+	err := meetingRenameCollection.FindOneAndUpdate(ctx,
+		bson.D{{"_id", from}, {"$or", bson.A{bson.D{{"to", to}}, bson.D{{"completed_at", bson.D{{"$exists", true}}}}}}},
+		bson.D{{"$set", bson.D{{"to", to}, {"started_at", time.Now()}}}, {"$unset", bson.D{{"completed_at", ""}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if mongo.IsDuplicateKeyError(err) {
+		pending, err := GetMeetingRename(from)
+		if err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("rename of meeting '%s' to '%s' is not completed, run it again first", from, pending.To)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return previous.To == to && previous.CompletedAt.IsZero(), nil
+}
+
+func completeMeetingRename(from string, to string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := meetingRenameCollection.UpdateOne(ctx,
+		bson.D{{"_id", from}, {"to", to}},
+		bson.D{{"$set", bson.D{{"completed_at", time.Now()}}}},
+	)
+	return err
+}
+
+// inMeetingBatches passes the documents matching the filter to fn in batches ordered by id, each batch runs in its own
+// transaction. Counting in fn has to happen in afterCommit, since a transaction may be retried.
+func inMeetingBatches[T any](ctx context.Context, collection *mongo.Collection, filter bson.D, fn func(ctx context.Context, documents []T) error) error {
+	var last primitive.ObjectID
+	for {
+		var count int
+		var next primitive.ObjectID
+
+		err := withTransaction(ctx, func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+
+			batchFilter := append(bson.D{{"_id", bson.D{{"$gt", last}}}}, filter...)
+			opts := options.Find().SetSort(bson.D{{"_id", 1}}).SetLimit(meetingBatchSize)
+			cursor, err := collection.Find(ctx, batchFilter, opts)
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			var documents []T
+			for cursor.Next(ctx) {
+				var document T
+				if err := cursor.Decode(&document); err != nil {
+					return err
+				}
+				documents = append(documents, document)
+				next = cursor.Current.Lookup("_id").ObjectID()
+			}
+			if err := cursor.Err(); err != nil {
+				return err
+			}
+
+			count = len(documents)
+			if count == 0 {
+				return nil
+			}
+			return fn(ctx, documents)
+		})
+		if err != nil {
+			return err
+		}
+		if count < meetingBatchSize {
+			return nil
+		}
+		last = next
+	}
+}
+
+// meetingRewrite is a document changed by a meeting removal or rename, without after it is deleted.
+type meetingRewrite struct {
+	id     primitive.ObjectID
+	before interface{}
+	after  interface{}
+}
+
+// applyMeetingRewrites writes a batch of rewritten documents with one bulk write and records their changes together.
+// Changes without a meeting of their own are audited for the given meeting.
+func applyMeetingRewrites(ctx context.Context, action string, collection *mongo.Collection, entity string, meeting string, rewrites []meetingRewrite) error {
+	var models []mongo.WriteModel
+	var changes []entityChange
+	for _, rewrite := range rewrites {
+		c, err := newEntityChange(ctx, action, entity, rewrite.id, rewrite.before, rewrite.after)
+		if err != nil {
+			return err
+		}
+		if c.meeting == "" {
+			c.meeting = meeting
+		}
+		changes = append(changes, c)
+
+		if rewrite.after == nil {
+			models = append(models, mongo.NewDeleteOneModel().SetFilter(bson.D{{"_id", rewrite.id}}))
+		} else {
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.D{{"_id", rewrite.id}}).SetReplacement(rewrite.after))
+		}
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if _, err := collection.BulkWrite(writeCtx, models); err != nil {
+		return err
+	}
+	return recordChanges(ctx, changes)
+}
+
+func renameParticipation(participation []string, firstMeeting string, from string, to string) ([]string, string) {
+	var renamed []string
+	for _, p := range participation {
+		if p == from {
+			p = to
+		}
+		renamed = misc.AppendWithoutDuplicates(renamed, p)
+	}
+
+	if firstMeeting == from {
+		firstMeeting = to
+	}
+	return renamed, firstMeeting
+}
+
+// removeParticipation pulls the meeting from the participation, the first remaining one replaces a removed first
+// meeting since participations are appended in the order of the imports.
+func removeParticipation(participation []string, firstMeeting string, meeting string) ([]string, string) {
+	var remaining []string
+	for _, p := range participation {
+		if p != meeting {
+			remaining = append(remaining, p)
+		}
+	}
+
+	if firstMeeting == meeting {
+		firstMeeting = ""
+		if len(remaining) > 0 {
+			firstMeeting = remaining[0]
+		}
+	}
+	return remaining, firstMeeting
+}
+
+func countMeetingRemoval(report *dto.MeetingRemovalDto, before string, after string, participation []string) {
+	if before != after {
+		report.FirstMeetingChanged++
+	}
+	if len(participation) == 0 {
+		report.WithoutParticipation++
+	}
+}
+
+func addMeetingRemoval(report *dto.MeetingRemovalDto, batch dto.MeetingRemovalDto) {
+	report.Athletes += batch.Athletes
+	report.Teams += batch.Teams
+	report.FirstMeetingChanged += batch.FirstMeetingChanged
+	report.WithoutParticipation += batch.WithoutParticipation
+}
+
+func findAll(ctx context.Context, collection *mongo.Collection, filter interface{}, results interface{}) error {
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+func replaceWithChange(ctx context.Context, action string, collection *mongo.Collection, entity string, id primitive.ObjectID, before interface{}, after interface{}) error {
+	_, err := collection.ReplaceOne(ctx, bson.D{{"_id", id}}, after)
+	if err != nil {
+		return err
+	}
+	return recordChange(ctx, action, entity, id, before, after)
+}
+
+type meetingCount struct {
+	Meeting string `bson:"_id"`
+	Count   int    `bson:"count"`
+	New     int    `bson:"new"`
+}
+
+func aggregateAll(ctx context.Context, collection *mongo.Collection, pipeline interface{}, results interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+// participationCounts counts the documents per meeting of their participation and those whose first meeting it was.
+func participationCounts(ctx context.Context, collection *mongo.Collection, match bson.D) ([]meetingCount, error) {
+	pipeline := mongo.Pipeline{
+		{{"$match", match}},
+		{{"$unwind", "$participation"}},
+		{{"$match", match}},
+		{{"$group", bson.D{
+			{"_id", "$participation"},
+			{"count", bson.D{{"$sum", 1}}},
+			{"new", bson.D{{"$sum", bson.D{{"$cond", bson.A{bson.D{{"$eq", bson.A{"$first_meeting", "$participation"}}}, 1, 0}}}}}},
+		}}},
+	}
+
+	var counts []meetingCount
+	err := aggregateAll(ctx, collection, pipeline, &counts)
+	return counts, err
+}
+
+// meetingSummaryRow is a meeting with its counts as grouped by GetMeetings.
+type meetingSummaryRow struct {
+	Meeting      string `bson:"_id"`
+	Athletes     int    `bson:"athletes"`
+	NewAthletes  int    `bson:"new_athletes"`
+	Teams        int    `bson:"teams"`
+	Certificates int    `bson:"certificates"`
+}
+
+// GetMeetings lists the meetings found in participations and certificates with their counts, ordered by meeting id.
+// The participations of athletes and teams and the certificates are grouped by meeting in a single aggregation, which
+// also pages the meetings by their id like the documents in findPage.
+func GetMeetings(paging Paging) ([]dto.MeetingSummaryDto, Page, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	size := paging.pageSize()
+	paged := mongo.Pipeline{}
+	if paging.Cursor != "" {
+		values, err := decodeCursor(paging.Cursor, 1)
+		if err != nil {
+			return []dto.MeetingSummaryDto{}, Page{}, err
+		}
+		after, _ := values[0].(string)
+		paged = append(paged, bson.D{{"$match", bson.D{{"_id", bson.D{{"$gt", after}}}}}})
+	}
+	paged = append(paged, bson.D{{"$sort", bson.D{{"_id", 1}}}})
+	if paging.Cursor == "" && paging.Offset > 0 {
+		paged = append(paged, bson.D{{"$skip", paging.Offset}})
+	}
+	paged = append(paged, bson.D{{"$limit", size + 1}})
+
+	pipeline := mongo.Pipeline{
+		{{"$unwind", "$participation"}},
+		{{"$project", bson.D{
+			{"meeting", "$participation"},
+			{"athletes", bson.D{{"$literal", 1}}},
+			{"new_athletes", bson.D{{"$cond", bson.A{bson.D{{"$eq", bson.A{"$first_meeting", "$participation"}}}, 1, 0}}}},
+		}}},
+		{{"$unionWith", bson.D{{"coll", teamCollection.Name()}, {"pipeline", mongo.Pipeline{
+			{{"$unwind", "$participation"}},
+			{{"$project", bson.D{{"meeting", "$participation"}, {"teams", bson.D{{"$literal", 1}}}}}},
+		}}}}},
+		{{"$unionWith", bson.D{{"coll", certificateCollection.Name()}, {"pipeline", mongo.Pipeline{
+			{{"$project", bson.D{{"meeting", "$meeting"}, {"certificates", bson.D{{"$literal", 1}}}}}},
+		}}}}},
+		{{"$match", bson.D{{"meeting", bson.D{{"$nin", bson.A{"", nil}}}}}}},
+		{{"$group", bson.D{
+			{"_id", "$meeting"},
+			{"athletes", bson.D{{"$sum", "$athletes"}}},
+			{"new_athletes", bson.D{{"$sum", "$new_athletes"}}},
+			{"teams", bson.D{{"$sum", "$teams"}}},
+			{"certificates", bson.D{{"$sum", "$certificates"}}},
+		}}},
+		{{"$facet", bson.D{
+			{"total", mongo.Pipeline{{{"$count", "count"}}}},
+			{"meetings", paged},
+		}}},
+	}
+
+	cursor, err := athleteCollection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return []dto.MeetingSummaryDto{}, Page{}, err
+	}
+
+	var facets []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Meetings []meetingSummaryRow `bson:"meetings"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return []dto.MeetingSummaryDto{}, Page{}, err
+	}
+
+	result := []dto.MeetingSummaryDto{}
+	var page Page
+	if len(facets) > 0 {
+		if len(facets[0].Total) > 0 {
+			page.Total = facets[0].Total[0].Count
+		}
+		for _, row := range facets[0].Meetings {
+			if int64(len(result)) == size {
+				page.Next, err = encodeCursor(bson.A{result[len(result)-1].Meeting})
+				if err != nil {
+					return []dto.MeetingSummaryDto{}, Page{}, err
+				}
+				break
+			}
+			result = append(result, dto.MeetingSummaryDto{
+				Meeting:      row.Meeting,
+				Athletes:     row.Athletes,
+				NewAthletes:  row.NewAthletes,
+				Teams:        row.Teams,
+				Certificates: row.Certificates,
+			})
+		}
+	}
+
+	if paging.Stream != nil {
+		if err := paging.Stream.Start(page.Total); err != nil {
+			return []dto.MeetingSummaryDto{}, Page{}, err
+		}
+		for _, meeting := range result {
+			if err := paging.Stream.Send(meeting); err != nil {
+				return []dto.MeetingSummaryDto{}, Page{}, err
+			}
+		}
+		return []dto.MeetingSummaryDto{}, page, nil
+	}
+	return result, page, nil
+}
+
+// GetMeetingSummary returns the counts of a meeting together with the gender and birth year distribution of its
+// athletes.
+func GetMeetingSummary(meeting string) (dto.MeetingSummaryDto, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	summary := dto.MeetingSummaryDto{Meeting: meeting, Genders: map[string]int{}, Years: map[int]int{}}
+	match := bson.D{{"participation", meeting}}
+
+	athletes, err := participationCounts(ctx, athleteCollection, match)
+	if err != nil {
+		return dto.MeetingSummaryDto{}, err
+	}
+	for _, count := range athletes {
+		summary.Athletes = count.Count
+		summary.NewAthletes = count.New
+	}
+
+	teams, err := teamCollection.CountDocuments(ctx, match)
+	if err != nil {
+		return dto.MeetingSummaryDto{}, err
+	}
+	summary.Teams = int(teams)
+
+	certificates, err := certificateCollection.CountDocuments(ctx, bson.D{{"meeting", meeting}})
+	if err != nil {
+		return dto.MeetingSummaryDto{}, err
+	}
+	summary.Certificates = int(certificates)
+
+	if summary.Athletes == 0 && summary.Teams == 0 && summary.Certificates == 0 {
+		return dto.MeetingSummaryDto{}, ErrNotFound
+	}
+
+	var genders []struct {
+		Gender string `bson:"_id"`
+		Count  int    `bson:"count"`
+	}
+	err = aggregateAll(ctx, athleteCollection, mongo.Pipeline{
+		{{"$match", match}},
+		{{"$group", bson.D{{"_id", "$gender"}, {"count", bson.D{{"$sum", 1}}}}}},
+	}, &genders)
+	if err != nil {
+		return dto.MeetingSummaryDto{}, err
+	}
+	for _, gender := range genders {
+		if gender.Gender == "" {
+			gender.Gender = "unknown"
+		}
+		summary.Genders[gender.Gender] += gender.Count
+	}
+
+	var years []struct {
+		Year  int `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	err = aggregateAll(ctx, athleteCollection, mongo.Pipeline{
+		{{"$match", match}},
+		{{"$group", bson.D{{"_id", "$year"}, {"count", bson.D{{"$sum", 1}}}}}},
+	}, &years)
+	if err != nil {
+		return dto.MeetingSummaryDto{}, err
+	}
+	for _, year := range years {
+		summary.Years[year.Year] += year.Count
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"testing"
+)
+
+func TestRemoveParticipation(t *testing.T) {
+	tests := []struct {
+		name              string
+		participation     []string
+		firstMeeting      string
+		meeting           string
+		wantParticipation []string
+		wantFirstMeeting  string
+	}{
+		{"other meeting", []string{"IESC13", "IESC14"}, "IESC13", "IESC15", []string{"IESC13", "IESC14"}, "IESC13"},
+		{"later meeting", []string{"IESC13", "IESC14", "IESC15"}, "IESC13", "IESC14", []string{"IESC13", "IESC15"}, "IESC13"},
+		{"first meeting", []string{"IESC13", "IESC14", "IESC15"}, "IESC13", "IESC13", []string{"IESC14", "IESC15"}, "IESC14"},
+		{"only meeting", []string{"IESC13"}, "IESC13", "IESC13", nil, ""},
+		{"first meeting without participation", nil, "IESC13", "IESC13", nil, ""},
+		{"duplicate participation", []string{"IESC13", "IESC14", "IESC13"}, "IESC13", "IESC13", []string{"IESC14"}, "IESC14"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			participation, firstMeeting := removeParticipation(test.participation, test.firstMeeting, test.meeting)
+			if fmt.Sprint(participation) != fmt.Sprint(test.wantParticipation) || firstMeeting != test.wantFirstMeeting {
+				t.Errorf("got %v, %q, want %v, %q", participation, firstMeeting, test.wantParticipation, test.wantFirstMeeting)
+			}
+		})
+	}
+}
+
+func TestCountMeetingRemoval(t *testing.T) {
+	tests := []struct {
+		name          string
+		before        string
+		after         string
+		participation []string
+		want          dto.MeetingRemovalDto
+	}{
+		{"unchanged first meeting", "IESC13", "IESC13", []string{"IESC13"}, dto.MeetingRemovalDto{}},
+		{"changed first meeting", "IESC13", "IESC14", []string{"IESC14"}, dto.MeetingRemovalDto{FirstMeetingChanged: 1}},
+		{"without participation", "IESC13", "", nil, dto.MeetingRemovalDto{FirstMeetingChanged: 1, WithoutParticipation: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := dto.MeetingRemovalDto{}
+			countMeetingRemoval(&report, test.before, test.after, test.participation)
+			if fmt.Sprint(report) != fmt.Sprint(test.want) {
+				t.Errorf("got %+v, want %+v", report, test.want)
+			}
+		})
+	}
+}
+
+func TestRemoveMeeting(t *testing.T) {
+	initTestDatabase(t)
+
+	team, _, err := ImportTeam(context.Background(), model.Team{Name: "SV Test"}, "IESC13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	athlete, _, err := ImportAthlete(context.Background(), model.Athlete{Name: "Meier, Simon", Year: 2010, Team: team}, "IESC13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ImportAthlete(context.Background(), model.Athlete{Name: "Meier, Simon", Year: 2010, Team: team}, "IESC14"); err != nil {
+		t.Fatal(err)
+	}
+
+	dryRun, err := RemoveMeeting(context.Background(), "IESC13", "", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := RemoveMeeting(context.Background(), "IESC13", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := dto.MeetingRemovalDto{Meeting: "IESC13", Athletes: 1, Teams: 1, FirstMeetingChanged: 2, WithoutParticipation: 1}
+	if dryRun.DryRun = false; dryRun != want {
+		t.Errorf("dry run: got %+v, want %+v", dryRun, want)
+	}
+	if report != want {
+		t.Errorf("got %+v, want %+v", report, want)
+	}
+
+	stored, err := GetAthleteById(athlete.Identifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(stored.Participation) != "[IESC14]" || stored.FirstMeeting != "IESC14" {
+		t.Errorf("got participation %v and first meeting %q", stored.Participation, stored.FirstMeeting)
+	}
+}
+
+func TestRenameParticipation(t *testing.T) {
+	tests := []struct {
+		name              string
+		participation     []string
+		firstMeeting      string
+		wantParticipation []string
+		wantFirstMeeting  string
+	}{
+		{"other meetings", []string{"IESC14", "IESC15"}, "IESC14", []string{"IESC14", "IESC15"}, "IESC14"},
+		{"first meeting", []string{"IESC13", "IESC14"}, "IESC13", []string{"IESC99", "IESC14"}, "IESC99"},
+		{"later meeting", []string{"IESC14", "IESC13"}, "IESC14", []string{"IESC14", "IESC99"}, "IESC14"},
+		{"merged with new meeting", []string{"IESC13", "IESC14", "IESC99"}, "IESC13", []string{"IESC99", "IESC14"}, "IESC99"},
+		{"first meeting without participation", nil, "IESC13", nil, "IESC99"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			participation, firstMeeting := renameParticipation(test.participation, test.firstMeeting, "IESC13", "IESC99")
+			if fmt.Sprint(participation) != fmt.Sprint(test.wantParticipation) || firstMeeting != test.wantFirstMeeting {
+				t.Errorf("got %v, %q, want %v, %q", participation, firstMeeting, test.wantParticipation, test.wantFirstMeeting)
+			}
+		})
+	}
+}
+
+func TestRenameMeetingResume(t *testing.T) {
+	initTestDatabase(t)
+
+	if _, _, err := ImportAthlete(context.Background(), model.Athlete{Name: "Meier, Simon", Year: 2010}, "IESC13"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a rename that stopped part way is left pending
+	if _, err := startMeetingRename("IESC13", "IESC99"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		to          string
+		wantResumed bool
+		wantErr     bool
+	}{
+		{"other target while pending", "IESC98", false, true},
+		{"resumed", "IESC99", true, false},
+		{"again after completion", "IESC99", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report, err := RenameMeeting(context.Background(), "IESC13", test.to, false)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if report.Resumed != test.wantResumed {
+				t.Errorf("got resumed %t, want %t", report.Resumed, test.wantResumed)
+			}
+		})
+	}
+
+	rename, err := GetMeetingRename("IESC13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rename.To != "IESC99" || rename.CompletedAt.IsZero() {
+		t.Errorf("got %+v, want completed rename to IESC99", rename)
+	}
+}
+
+func TestGetMeetings(t *testing.T) {
+	initTestDatabase(t)
+
+	team, _, err := ImportTeam(context.Background(), model.Team{Name: "SV Test"}, "IESC13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	athlete, _, err := ImportAthlete(context.Background(), model.Athlete{Name: "Meier, Simon", Year: 2010, Team: team}, "IESC13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ImportAthlete(context.Background(), model.Athlete{Name: "Meier, Simon", Year: 2010, Team: team}, "IESC14"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ImportAthlete(context.Background(), model.Athlete{Name: "Schulze, Anna", Year: 2011, Team: team}, "IESC14"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddCertificate(context.Background(), model.Certificate{Name: "Urkunde", AthleteId: athlete.Identifier, Meeting: "IESC15"}); err != nil {
+		t.Fatal(err)
+	}
+
+	all := []dto.MeetingSummaryDto{
+		{Meeting: "IESC13", Athletes: 1, NewAthletes: 1, Teams: 1},
+		{Meeting: "IESC14", Athletes: 2, NewAthletes: 1},
+		{Meeting: "IESC15", Certificates: 1},
+	}
+
+	tests := []struct {
+		name   string
+		paging Paging
+		want   []dto.MeetingSummaryDto
+		next   bool
+	}{
+		{"all", Paging{}, all, false},
+		{"first page", Paging{Limit: 2}, all[:2], true},
+		{"offset", Paging{Limit: 2, Offset: 2}, all[2:], false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			meetings, page, err := GetMeetings(test.paging)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(meetings) != fmt.Sprint(test.want) {
+				t.Errorf("got %+v, want %+v", meetings, test.want)
+			}
+			if page.Total != int64(len(all)) || (page.Next != "") != test.next {
+				t.Errorf("got page %+v, want total %d and next %t", page, len(all), test.next)
+			}
+		})
+	}
+
+	_, page, err := GetMeetings(Paging{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	meetings, page, err := GetMeetings(Paging{Limit: 2, Cursor: page.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(meetings) != fmt.Sprint(all[2:]) || page.Next != "" {
+		t.Errorf("next page: got %+v and next %q, want %+v", meetings, page.Next, all[2:])
+	}
+
+	if _, err := GetMeetingSummary("IESC99"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v for unknown meeting, want %v", err, ErrNotFound)
+	}
+}
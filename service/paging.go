@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+// MaxPageSize is the largest number of entries a list returns at once, it is also used if no limit is given.
+const MaxPageSize = 1000
+
+// MaxStreamSize is the largest number of entries a streamed list sends, it is also used if no limit is given. Streams
+// are not held in memory, but still bounded by the time a query may take. A stream of a longer list ends after
+// MaxStreamSize entries, the total count tells the client to read the rest with an offset.
+const MaxStreamSize = 50000
+
+var ErrInvalidCursor = errors.New("given cursor is invalid")
+var ErrInvalidSort = errors.New("given sort is invalid")
+
+type Paging struct {
+	Limit  int
+	Offset int
+	Query  string
+	Cursor string
+	Sort   string
+	Fields Fields
+	Stream Stream
+}
+
+// Stream receives the documents of a list one by one as they are read, instead of having them collected in a page.
+type Stream interface {
+	// Start is called with the total count before the first document is sent.
+	Start(total int64) error
+	Send(document interface{}) error
+}
+
+// Page describes a returned page of a list. Next is the cursor of the following page, it is empty on the last page.
+type Page struct {
+	Total int64
+	Next  string
+}
+
+// sortDocument translates the comma separated sort fields of the paging, each prefixed with - for descending order,
+// into a sort document. fields maps the accepted sort fields to the document keys they order by, fallback is used
+// without sort.
+func (p *Paging) sortDocument(fields map[string][]string, fallback bson.D) (bson.D, error) {
+	if p.Sort == "" {
+		return fallback, nil
+	}
+
+	sort := bson.D{}
+	for _, field := range strings.Split(p.Sort, ",") {
+		// a leading + arrives as space
+		field = strings.TrimPrefix(strings.TrimSpace(field), "+")
+		direction := 1
+		if strings.HasPrefix(field, "-") {
+			direction = -1
+			field = field[1:]
+		}
+
+		keys, ok := fields[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, field)
+		}
+		for _, key := range keys {
+			if !hasSortKey(sort, key) {
+				sort = append(sort, bson.E{Key: key, Value: direction})
+			}
+		}
+	}
+	return sort, nil
+}
+
+// pageSize returns the number of documents of a page, which is at most MaxStreamSize for streams and MaxPageSize
+// otherwise.
+func (p *Paging) pageSize() int64 {
+	limit := MaxPageSize
+	if p.Stream != nil {
+		limit = MaxStreamSize
+	}
+	if p.Limit <= 0 || p.Limit > limit {
+		return int64(limit)
+	}
+	return int64(p.Limit)
+}
+
+// timeout returns the time a list query may take, streams of up to MaxStreamSize documents take longer than a page.
+func (p *Paging) timeout() time.Duration {
+	if p.Stream != nil {
+		return 5 * time.Minute
+	}
+	return 10 * time.Second
+}
+
+// findPage finds a page of the documents matching the filter in the given order, each of them is passed to prepare
+// if given. The order is completed by _id, so the next page continues right after the last document of this one, no
+// matter what was added or removed in between. The offset is only used if no cursor is given. Further find options
+// like collation and projection are taken from opts, which may be nil, the collation also applies to the count.
+// With a stream the documents are sent to it as they are read instead of being returned.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, sort bson.D, opts *options.FindOptions, paging Paging, prepare func(T) T) ([]T, Page, error) {
+	if opts == nil {
+		opts = options.Find()
+	}
+
+	total, err := collection.CountDocuments(ctx, filter, options.Count().SetCollation(opts.Collation))
+	if err != nil {
+		return []T{}, Page{}, err
+	}
+
+	if !hasSortKey(sort, "_id") {
+		direction := 1
+		if len(sort) > 0 {
+			direction = sortDirection(sort[len(sort)-1])
+		}
+		sort = append(sort, bson.E{Key: "_id", Value: direction})
+	}
+
+	size := paging.pageSize()
+	opts.SetSort(sort).SetLimit(size + 1)
+	if paging.Cursor != "" {
+		values, err := decodeCursor(paging.Cursor, len(sort))
+		if err != nil {
+			return []T{}, Page{}, err
+		}
+		filter = bson.D{{"$and", bson.A{filter, afterCursor(sort, values)}}}
+	} else if paging.Offset > 0 {
+		opts.SetSkip(int64(paging.Offset))
+	}
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return []T{}, Page{}, err
+	}
+	defer cursor.Close(ctx)
+
+	if paging.Stream != nil {
+		if err := paging.Stream.Start(total); err != nil {
+			return []T{}, Page{}, err
+		}
+	}
+
+	results := []T{}
+	page := Page{Total: total}
+	var last bson.Raw
+	for n := int64(0); cursor.Next(ctx); n++ {
+		if n == size {
+			page.Next, err = encodeCursor(cursorValues(sort, last))
+			if err != nil {
+				return []T{}, Page{}, err
+			}
+			break
+		}
+		last = append(last[:0], cursor.Current...)
+
+		var document T
+		if err := cursor.Decode(&document); err != nil {
+			return []T{}, Page{}, err
+		}
+		if prepare != nil {
+			document = prepare(document)
+		}
+
+		if paging.Stream != nil {
+			err = paging.Stream.Send(document)
+		} else {
+			results = append(results, document)
+		}
+		if err != nil {
+			return []T{}, Page{}, err
+		}
+	}
+
+	return results, page, cursor.Err()
+}
+
+func hasSortKey(sort bson.D, key string) bool {
+	for _, e := range sort {
+		if e.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func sortKeys(sort bson.D) []string {
+	var keys []string
+	for _, e := range sort {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func sortDirection(e bson.E) int {
+	switch v := e.Value.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	}
+	return 1
+}
+
+// cursorValues returns the values of the sort keys of a document, missing values are nil.
+func cursorValues(sort bson.D, document bson.Raw) bson.A {
+	values := bson.A{}
+	for _, e := range sort {
+		value, err := document.LookupErr(strings.Split(e.Key, ".")...)
+		if err != nil {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// encodeCursor encodes the sort key values of the last document of a page, bson keeps their types.
+func encodeCursor(values bson.A) (string, error) {
+	data, err := bson.Marshal(bson.D{{"v", values}})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string, keys int) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var decoded struct {
+		Values []interface{} `bson:"v"`
+	}
+	if err := bson.Unmarshal(data, &decoded); err != nil || len(decoded.Values) != keys {
+		return nil, ErrInvalidCursor
+	}
+	return decoded.Values, nil
+}
+
+// afterCursor matches the documents following the given sort key values: those equal in the first keys and after
+// the value of the next key. Missing values sort before all others.
+func afterCursor(sort bson.D, values []interface{}) bson.D {
+	var or bson.A
+	for i, e := range sort {
+		var after bson.E
+		switch {
+		case sortDirection(e) >= 0 && values[i] == nil:
+			after = bson.E{Key: e.Key, Value: bson.D{{"$ne", nil}}}
+		case sortDirection(e) >= 0:
+			after = bson.E{Key: e.Key, Value: bson.D{{"$gt", values[i]}}}
+		case values[i] == nil:
+			// nothing sorts after missing values in descending order
+			continue
+		default:
+			after = bson.E{Key: "$or", Value: bson.A{
+				bson.D{{e.Key, bson.D{{"$lt", values[i]}}}},
+				bson.D{{e.Key, nil}},
+			}}
+		}
+
+		condition := bson.D{}
+		for j := 0; j < i; j++ {
+			condition = append(condition, bson.E{Key: sort[j].Key, Value: values[j]})
+		}
+		or = append(or, append(condition, after))
+	}
+
+	if len(or) == 0 {
+		return bson.D{{"_id", bson.D{{"$exists", false}}}}
+	}
+	return bson.D{{"$or", or}}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	addedAt := primitive.NewDateTimeFromTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name   string
+		values bson.A
+	}{
+		{"string and id", bson.A{"Meier", id}},
+		{"number", bson.A{int32(2010), id}},
+		{"missing value", bson.A{nil, id}},
+		{"date", bson.A{addedAt, id}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := encodeCursor(test.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values, err := decodeCursor(cursor, len(test.values))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(bson.A(values), test.values) {
+				t.Errorf("got %#v, want %#v", values, test.values)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	valid, err := encodeCursor(bson.A{"Meier", primitive.NewObjectID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		keys   int
+	}{
+		{"not base64", "not a cursor!", 2},
+		{"not bson", "bm90IGJzb24", 2},
+		{"other number of keys", valid, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := decodeCursor(test.cursor, test.keys); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestAfterCursor(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name   string
+		sort   bson.D
+		values []interface{}
+		want   bson.D
+	}{
+		{
+			name:   "ascending",
+			sort:   bson.D{{"name", 1}, {"_id", 1}},
+			values: []interface{}{"Meier", id},
+			want: bson.D{{"$or", bson.A{
+				bson.D{{"name", bson.D{{"$gt", "Meier"}}}},
+				bson.D{{"name", "Meier"}, {"_id", bson.D{{"$gt", id}}}},
+			}}},
+		},
+		{
+			name:   "descending",
+			sort:   bson.D{{"year", -1}, {"_id", -1}},
+			values: []interface{}{int32(2010), id},
+			want: bson.D{{"$or", bson.A{
+				bson.D{{"$or", bson.A{bson.D{{"year", bson.D{{"$lt", int32(2010)}}}}, bson.D{{"year", nil}}}}},
+				bson.D{{"year", int32(2010)}, {"$or", bson.A{bson.D{{"_id", bson.D{{"$lt", id}}}}, bson.D{{"_id", nil}}}}},
+			}}},
+		},
+		{
+			name:   "missing value ascending",
+			sort:   bson.D{{"dsv_id", 1}, {"_id", 1}},
+			values: []interface{}{nil, id},
+			want: bson.D{{"$or", bson.A{
+				bson.D{{"dsv_id", bson.D{{"$ne", nil}}}},
+				bson.D{{"dsv_id", nil}, {"_id", bson.D{{"$gt", id}}}},
+			}}},
+		},
+		{
+			name:   "missing value descending",
+			sort:   bson.D{{"dsv_id", -1}, {"_id", -1}},
+			values: []interface{}{nil, id},
+			want: bson.D{{"$or", bson.A{
+				bson.D{{"dsv_id", nil}, {"$or", bson.A{bson.D{{"_id", bson.D{{"$lt", id}}}}, bson.D{{"_id", nil}}}}},
+			}}},
+		},
+		{
+			name:   "nothing after",
+			sort:   bson.D{{"_id", -1}},
+			values: []interface{}{nil},
+			want:   bson.D{{"_id", bson.D{{"$exists", false}}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := afterCursor(test.sort, test.values); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCursorValues(t *testing.T) {
+	id := primitive.NewObjectID()
+	document, err := bson.Marshal(bson.D{{"_id", id}, {"name", "Simon Meier"}, {"team", bson.D{{"name", "SV Test"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := cursorValues(bson.D{{"team.name", 1}, {"year", 1}, {"_id", 1}}, document)
+	if len(values) != 3 {
+		t.Fatalf("got %d values, want 3", len(values))
+	}
+	if name, ok := values[0].(bson.RawValue); !ok || name.StringValue() != "SV Test" {
+		t.Errorf("got team name %v, want SV Test", values[0])
+	}
+	if values[1] != nil {
+		t.Errorf("got year %v, want nil", values[1])
+	}
+	if value, ok := values[2].(bson.RawValue); !ok || value.ObjectID() != id {
+		t.Errorf("got id %v, want %s", values[2], id.Hex())
+	}
+}
+
+// discardStream drops the streamed documents, only its presence is relevant to the paging.
+type discardStream struct{}
+
+func (discardStream) Start(int64) error      { return nil }
+func (discardStream) Send(interface{}) error { return nil }
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		limit  int
+		stream bool
+		want   int64
+	}{
+		{0, false, MaxPageSize},
+		{-1, false, MaxPageSize},
+		{20, false, 20},
+		{MaxPageSize, false, MaxPageSize},
+		{MaxPageSize + 1, false, MaxPageSize},
+		{0, true, MaxStreamSize},
+		{20, true, 20},
+		{MaxPageSize + 1, true, MaxPageSize + 1},
+		{MaxStreamSize, true, MaxStreamSize},
+		{MaxStreamSize + 1, true, MaxStreamSize},
+	}
+
+	for _, test := range tests {
+		paging := Paging{Limit: test.limit}
+		if test.stream {
+			paging.Stream = discardStream{}
+		}
+		if got := paging.pageSize(); got != test.want {
+			t.Errorf("pageSize() with limit %d and stream %v = %d, want %d", test.limit, test.stream, got, test.want)
+		}
+	}
+}
+
+func TestSortDocument(t *testing.T) {
+	fallback := bson.D{{"name", 1}}
+
+	tests := []struct {
+		sort    string
+		want    bson.D
+		wantErr bool
+	}{
+		{"", fallback, false},
+		{"year", bson.D{{"year", 1}}, false},
+		{"-year", bson.D{{"year", -1}}, false},
+		{"+year", bson.D{{"year", 1}}, false},
+		{" year", bson.D{{"year", 1}}, false},
+		{"lastname", bson.D{{"lastname", 1}, {"firstname", 1}}, false},
+		{"-lastname", bson.D{{"lastname", -1}, {"firstname", -1}}, false},
+		{"firstname,-lastname", bson.D{{"firstname", 1}, {"lastname", 1}}, false},
+		{"-year,name", bson.D{{"year", -1}, {"name", 1}}, false},
+		{"team", nil, true},
+		{"year,", nil, true},
+		{"_id", nil, true},
+	}
+
+	for _, test := range tests {
+		paging := Paging{Sort: test.sort}
+		got, err := paging.sortDocument(athleteSortFields, fallback)
+		if test.wantErr {
+			if !errors.Is(err, ErrInvalidSort) {
+				t.Errorf("sortDocument(%q): got error %v, want %v", test.sort, err, ErrInvalidSort)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("sortDocument(%q): %s", test.sort, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("sortDocument(%q) = %v, want %v", test.sort, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/swimresults/athlete-service/dto"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"slices"
+	"sort"
+	"time"
+)
+
+var ErrInvalidInclude = errors.New("invalid include")
+
+// GetAthleteProfile returns the athlete together with the included parts of its profile, all of them if include is
+// empty.
+func GetAthleteProfile(id primitive.ObjectID, include []string) (dto.AthleteProfileDto, error) {
+	for _, part := range include {
+		if !slices.Contains(dto.ProfileIncludes, part) {
+			return dto.AthleteProfileDto{}, fmt.Errorf("%w: %s is unknown", ErrInvalidInclude, part)
+		}
+	}
+	included := func(part string) bool {
+		return len(include) == 0 || slices.Contains(include, part)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var profile dto.AthleteProfileDto
+	err := findDocumentById(ctx, athleteCollection, id, &profile.Athlete)
+	if err != nil {
+		return dto.AthleteProfileDto{}, err
+	}
+
+	if included(dto.ProfileIncludeTeam) {
+		profile.Athlete = withTeam(ctx, profile.Athlete, nil)
+	}
+
+	if included(dto.ProfileIncludeTeamHistory) {
+		profile.TeamHistory, err = getTeamHistory(ctx, id)
+		if err != nil {
+			return dto.AthleteProfileDto{}, err
+		}
+	}
+
+	if included(dto.ProfileIncludeCertificates) {
+		profile.Certificates, err = getVisibleCertificates(ctx, profile.Athlete)
+		if err != nil {
+			return dto.AthleteProfileDto{}, err
+		}
+	}
+
+	if included(dto.ProfileIncludeMeetings) {
+		profile.Meetings = profile.Athlete.Participation
+	}
+	return profile, nil
+}
+
+// getTeamHistory reads the teams the athlete was assigned to from the audit log, oldest first. Assignments from before
+// the audit log was written are unknown.
+func getTeamHistory(ctx context.Context, id primitive.ObjectID) ([]dto.TeamHistoryEntryDto, error) {
+	var entries []model.AuditEntry
+	cursor, err := auditCollection.Find(ctx,
+		bson.D{{"entity", "athlete"}, {"entity_id", id}, {"changes.field", "team_id"}},
+		options.Find().SetSort(bson.D{{"created_at", 1}, {"_id", 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	var history []dto.TeamHistoryEntryDto
+	var teamIds []primitive.ObjectID
+	for _, entry := range entries {
+		for _, change := range entry.Changes {
+			teamId, ok := change.To.(primitive.ObjectID)
+			if change.Field != "team_id" || !ok {
+				continue
+			}
+			history = append(history, dto.TeamHistoryEntryDto{TeamId: teamId, Meeting: entry.Meeting, Since: entry.CreatedAt})
+			teamIds = append(teamIds, teamId)
+		}
+	}
+
+	teams, err := findTeamsById(ctx, teamIds)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		history[i].Name = teams[history[i].TeamId].Name
+	}
+	return history, nil
+}
+
+// getVisibleCertificates groups the certificates of the athlete which are not hidden by meeting, in the order of its
+// participation, and orders them within a meeting.
+func getVisibleCertificates(ctx context.Context, athlete model.Athlete) ([]dto.MeetingCertificatesDto, error) {
+	var certificates []model.Certificate
+	cursor, err := certificateCollection.Find(ctx,
+		bson.D{{"athlete_id", athlete.Identifier}, {"hidden", bson.D{{"$ne", true}}}},
+		options.Find().SetSort(bson.D{{"ordering", 1}, {"_id", 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &certificates); err != nil {
+		return nil, err
+	}
+
+	byMeeting := map[string][]model.Certificate{}
+	var meetings []string
+	for _, certificate := range certificates {
+		if _, ok := byMeeting[certificate.Meeting]; !ok {
+			meetings = append(meetings, certificate.Meeting)
+		}
+		byMeeting[certificate.Meeting] = append(byMeeting[certificate.Meeting], certificate)
+	}
+
+	// meetings without participation follow the others
+	sort.SliceStable(meetings, func(i, j int) bool {
+		a, b := slices.Index(athlete.Participation, meetings[i]), slices.Index(athlete.Participation, meetings[j])
+		if a < 0 || b < 0 {
+			return a >= 0 && b < 0
+		}
+		return a < b
+	})
+
+	var groups []dto.MeetingCertificatesDto
+	for _, meeting := range meetings {
+		groups = append(groups, dto.MeetingCertificatesDto{Meeting: meeting, Certificates: byMeeting[meeting]})
+	}
+	return groups, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+)
+
+func TestGetAthleteProfileInvalidInclude(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+	}{
+		{"unknown include", []string{"results"}},
+		{"unknown among known includes", []string{"team", "certificate"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// includes are checked before the database is read
+			_, err := GetAthleteProfile(primitive.NewObjectID(), test.include)
+			if !errors.Is(err, ErrInvalidInclude) {
+				t.Errorf("got %v, want ErrInvalidInclude", err)
+			}
+		})
+	}
+}
+
+func TestGetVisibleCertificates(t *testing.T) {
+	initTestDatabase(t)
+
+	athlete := model.Athlete{Identifier: primitive.NewObjectID(), Participation: []string{"IESC13", "IESC14"}}
+	certificates := []model.Certificate{
+		{Name: "unknown meeting", Meeting: "OLD10"},
+		{Name: "second", Meeting: "IESC14", Ordering: 2},
+		{Name: "hidden", Meeting: "IESC14", Hidden: true},
+		{Name: "first", Meeting: "IESC14", Ordering: 1},
+		{Name: "earlier meeting", Meeting: "IESC13"},
+	}
+	for _, certificate := range certificates {
+		certificate.AthleteId = athlete.Identifier
+		if _, err := AddCertificate(context.Background(), certificate); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := getVisibleCertificates(context.Background(), athlete)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string][]string{}
+	var meetings []string
+	for _, group := range groups {
+		meetings = append(meetings, group.Meeting)
+		for _, certificate := range group.Certificates {
+			got[group.Meeting] = append(got[group.Meeting], certificate.Name)
+		}
+	}
+
+	if want := []string{"IESC13", "IESC14", "OLD10"}; !reflect.DeepEqual(meetings, want) {
+		t.Errorf("got meetings %v, want %v", meetings, want)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(got["IESC14"], want) {
+		t.Errorf("got certificates %v, want %v", got["IESC14"], want)
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var provenanceCollection *mongo.Collection
+
+type provenanceKey struct{}
+
+type provenanceInfo struct {
+	source  string
+	meeting string
+}
+
+func provenanceService(database *mongo.Database) {
+	provenanceCollection = database.Collection("provenance")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = provenanceCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"entity", 1}, {"entity_id", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+}
+
+// WithProvenanceSource returns a context that makes the writes run with it record the given source for the changed
+// fields. Without a source, writes are recorded as coming from an api client.
+func WithProvenanceSource(ctx context.Context, source string) context.Context {
+	info := provenanceFromContext(ctx)
+	info.source = source
+	return context.WithValue(ctx, provenanceKey{}, info)
+}
+
+// importSourceContext sets the source given with an import request, if there is one.
+func importSourceContext(ctx context.Context, source string) context.Context {
+	if source == "" {
+		return ctx
+	}
+	return WithProvenanceSource(ctx, source)
+}
+
+func withProvenanceMeeting(ctx context.Context, meeting string) context.Context {
+	info := provenanceFromContext(ctx)
+	info.meeting = meeting
+	return context.WithValue(ctx, provenanceKey{}, info)
+}
+
+func provenanceFromContext(ctx context.Context) provenanceInfo {
+	info, ok := ctx.Value(provenanceKey{}).(provenanceInfo)
+	if !ok || info.source == "" {
+		info.source = model.SourceApi
+	}
+	return info
+}
+
+func GetProvenance(entity string, id primitive.ObjectID) (model.Provenance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var provenance model.Provenance
+	err := provenanceCollection.FindOne(ctx, bson.D{{"entity", entity}, {"entity_id", id}}).Decode(&provenance)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Provenance{}, errors.New("no entry with given id found")
+		}
+		return model.Provenance{}, err
+	}
+
+	return provenance, nil
+}
+
+// recordProvenance stores source, meeting and time of the write for every changed field of the athletes and teams, the
+// provenance of deleted ones is removed.
+func recordProvenance(ctx context.Context, changes []entityChange) error {
+	info := provenanceFromContext(ctx)
+	field := model.FieldProvenance{
+		Source:    info.source,
+		Meeting:   info.meeting,
+		UpdatedAt: time.Now(),
+	}
+
+	var models []mongo.WriteModel
+	for _, c := range changes {
+		if c.entity != "athlete" && c.entity != "team" {
+			continue
+		}
+
+		filter := bson.D{{"entity", c.entity}, {"entity_id", c.id}}
+		if c.deleted {
+			models = append(models, mongo.NewDeleteOneModel().SetFilter(filter))
+			continue
+		}
+		if len(c.changes) == 0 {
+			continue
+		}
+
+		set := bson.D{}
+		for _, change := range c.changes {
+			set = append(set, bson.E{Key: "fields." + change.Field, Value: field})
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{{"$set", set}}).SetUpsert(true))
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := provenanceCollection.BulkWrite(ctx, models)
+	return err
+}
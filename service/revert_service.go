@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// RevertAthlete restores the athlete as it was right after the audited change given as version, a deleted athlete is
+// added again.
+func RevertAthlete(ctx context.Context, id primitive.ObjectID, version primitive.ObjectID) (model.Athlete, error) {
+	err := revertEntity(ctx, athleteCollection, "athlete", id, version)
+	if err != nil {
+		return model.Athlete{}, err
+	}
+	return GetAthleteById(id)
+}
+
+// RevertTeam restores the team as it was right after the audited change given as version, a deleted team is added
+// again.
+func RevertTeam(ctx context.Context, id primitive.ObjectID, version primitive.ObjectID) (model.Team, error) {
+	err := revertEntity(ctx, teamCollection, "team", id, version)
+	if err != nil {
+		return model.Team{}, err
+	}
+	return GetTeamById(id)
+}
+
+// revertEntity rebuilds the requested version by undoing all later audited changes on the current document, starting
+// with the newest one. The revert is recorded as a change itself.
+func revertEntity(ctx context.Context, collection *mongo.Collection, entity string, id primitive.ObjectID, version primitive.ObjectID) error {
+	return withTransaction(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		var current bson.M
+		err := findDocumentById(ctx, collection, id, &current)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		exists := err == nil
+
+		opts := options.Find().SetSort(bson.D{{"created_at", -1}, {"_id", -1}})
+		cursor, err := auditCollection.Find(ctx, bson.D{{"entity", entity}, {"entity_id", id}}, opts)
+		if err != nil {
+			return err
+		}
+
+		var entries []model.AuditEntry
+		if err := cursor.All(ctx, &entries); err != nil {
+			return err
+		}
+
+		reverted := bson.M{}
+		for k, v := range current {
+			reverted[k] = v
+		}
+
+		found := false
+		for _, entry := range entries {
+			if entry.Identifier == version {
+				found = true
+				break
+			}
+			for _, change := range entry.Changes {
+				if change.From == nil {
+					delete(reverted, change.Field)
+				} else {
+					reverted[change.Field] = change.From
+				}
+			}
+		}
+		if !found {
+			return errors.New("given version not found")
+		}
+
+		delete(reverted, "_id")
+		if len(reverted) == 0 {
+			return errors.New("entity did not exist in given version")
+		}
+		reverted["_id"] = id
+
+		if exists {
+			_, err = collection.ReplaceOne(ctx, bson.D{{"_id", id}}, reverted)
+		} else {
+			_, err = collection.InsertOne(ctx, reverted)
+		}
+		if err != nil {
+			return err
+		}
+
+		var before interface{}
+		if exists {
+			before = current
+		}
+		err = recordChange(ctx, model.AuditActionRevert, entity, id, before, reverted)
+		if err != nil {
+			return err
+		}
+
+		fields := log.Fields{"entity": entity, "entity_id": id, "version": version}
+		log.WithFields(fields).Info("entity reverted")
+		return nil
+	})
+}
@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -10,38 +14,170 @@ import (
 )
 
 var client *mongo.Client
+var transactionsSupported bool
+
+var ErrNotFound = errors.New("no entry with given id found")
+
+// instanceId identifies this instance in the jobs and locks it holds.
+var instanceId = func() string {
+	hostname, _ := os.Hostname()
+	return hostname + "/" + primitive.NewObjectID().Hex()
+}()
+
+// documentMOptions makes nested documents in interface{} fields decode as bson.M, so they are rendered as JSON objects.
+var documentMOptions = options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+
+// nameCollation orders athlete and team lists the way German start lists do, umlauts as their expanded vowels and
+// lower and upper case names next to each other. It is configured by SR_ATHLETE_COLLATION, "simple" compares binary.
+var nameCollation = &options.Collation{Locale: "de@collation=phonebook"}
 
 func Init(c *mongo.Client) {
 	database := c.Database(os.Getenv("SR_ATHLETE_MONGO_DATABASE"))
 	client = c
 
+	if locale := os.Getenv("SR_ATHLETE_COLLATION"); locale != "" {
+		nameCollation = &options.Collation{Locale: locale}
+	}
+
 	athleteService(database)
 	teamService(database)
 	certificateService(database)
+	meetingService(database)
+	importBatchService(database)
+	importLockService(database)
+	idempotencyService(database)
+	importJobService(database)
+	provenanceService(database)
+	fieldLockService(database)
+	auditService(database)
+	webhookService(database)
+	eventOutboxService(database)
+	changeVersionService(database)
+
+	transactionsSupported = supportsTransactions()
+	log.WithFields(log.Fields{"transactions": transactionsSupported}).Info("database initialized")
+
+	resumeImportJobs()
 }
 
-func PingDatabase() bool {
+// findDocumentById decodes the stored document with the given id, without embedding any referenced entities.
+func findDocumentById(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, v interface{}) error {
+	err := collection.FindOne(ctx, bson.D{{"_id", id}}).Decode(v)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+// ensureUniqueDsvIdIndex creates a unique index on dsv_id for all documents with a dsv_id set, so parallel imports
+// cannot create the same athlete or team twice. Creating the index fails as long as duplicates exist.
+func ensureUniqueDsvIdIndex(collection *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err := client.Ping(ctx, readpref.Primary())
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{"dsv_id", 1}},
+		Options: options.Index().
+			SetName("dsv_id_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.D{{"dsv_id", bson.D{{"$gt", 0}}}}),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"collection": collection.Name(), "error": err.Error()}).Warn("unable to create unique dsv_id index")
+	}
+}
+
+// ensureIndexes creates the indexes the queries of a collection rely on, existing indexes are left as they are. Each
+// index is created on its own, so one conflicting index, e.g. after the collation changed, does not prevent the others.
+func ensureIndexes(collection *mongo.Collection, indexes ...mongo.IndexModel) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, index := range indexes {
+		_, err := collection.Indexes().CreateOne(ctx, index)
+		if err != nil {
+			log.WithFields(log.Fields{"collection": collection.Name(), "keys": index.Keys, "error": err.Error()}).Warn("unable to create index")
+		}
+	}
+}
+
+// supportsTransactions checks whether the database is a replica set or a sharded cluster, a standalone server does not
+// support transactions.
+func supportsTransactions() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var result bson.M
+	err := client.Database("admin").RunCommand(ctx, bson.D{{"hello", 1}}).Decode(&result)
 	if err != nil {
 		return false
 	}
 
-	return true
+	_, replicaSet := result["setName"]
+	return replicaSet || result["msg"] == "isdbgrid"
 }
 
-type Paging struct {
-	Limit  int
-	Offset int
-	Query  string
+type afterCommitKey struct{}
+
+// withTransaction runs fn in a transaction if the database supports it, otherwise fn runs without one. Nested calls
+// join the transaction of the outer call.
+func withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !transactionsSupported || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	var callbacks []func()
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		// a retried transaction registers its callbacks again
+		callbacks = nil
+		return nil, fn(context.WithValue(sc, afterCommitKey{}, &callbacks))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, callback := range callbacks {
+		callback()
+	}
+	return nil
+}
+
+// afterCommit runs fn once the transaction of the context is committed, outside of a transaction it runs right away.
+func afterCommit(ctx context.Context, fn func()) {
+	if callbacks, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		*callbacks = append(*callbacks, fn)
+		return
+	}
+	fn()
+}
+
+// retryBackoff doubles the delay after every failed attempt, starting with ten seconds and capped at an hour.
+func retryBackoff(attempts int) time.Duration {
+	delay := 10 * time.Second
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
 }
 
-func (p *Paging) getPaginatedOpts() options.FindOptions {
-	l := int64(p.Limit)
-	skip := int64(p.Offset)
-	fOpt := options.FindOptions{Limit: &l, Skip: &skip}
-	return fOpt
+func PingDatabase() bool {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+
+	err := client.Ping(ctx, readpref.Primary())
+	if err != nil {
+		return false
+	}
+
+	return true
 }
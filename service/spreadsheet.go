@@ -0,0 +1,238 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// spreadsheetLine is a row of a spreadsheet with its 1-based number in the file, xlsx files leave out empty rows.
+type spreadsheetLine struct {
+	number int
+	cells  []string
+}
+
+// readSpreadsheet returns all rows of the given CSV or XLSX file, xlsx files are detected by their zip signature.
+func readSpreadsheet(data []byte) ([]spreadsheetLine, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return readXlsx(data)
+	}
+	return readCsv(data)
+}
+
+func readCsv(data []byte) ([]spreadsheetLine, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []spreadsheetLine
+	for i, record := range records {
+		lines = append(lines, spreadsheetLine{number: i + 1, cells: record})
+	}
+	return lines, nil
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelationId string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.Text
+	for _, r := range t.Runs {
+		s += r.Text
+	}
+	return s
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Reference string `xml:"r,attr"`
+		Cells     []struct {
+			Reference string   `xml:"r,attr"`
+			Type      string   `xml:"t,attr"`
+			Value     string   `xml:"v"`
+			Inline    xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXlsx reads the cell values of the first worksheet of an xlsx workbook.
+func readXlsx(data []byte) ([]spreadsheetLine, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXml(f, &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetPath := "xl/worksheets/sheet1.xml"
+	var workbook xlsxWorkbook
+	var relationships xlsxRelationships
+	if f, ok := files["xl/workbook.xml"]; ok && decodeZipXml(f, &workbook) == nil && len(workbook.Sheets) > 0 {
+		if f, ok := files["xl/_rels/workbook.xml.rels"]; ok && decodeZipXml(f, &relationships) == nil {
+			for _, r := range relationships.Relationships {
+				if r.Id == workbook.Sheets[0].RelationId {
+					if strings.HasPrefix(r.Target, "/") {
+						sheetPath = strings.TrimPrefix(r.Target, "/")
+					} else {
+						sheetPath = path.Join("xl", r.Target)
+					}
+				}
+			}
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("no worksheet found in xlsx file")
+	}
+
+	var sheet xlsxSheet
+	if err := decodeZipXml(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var lines []spreadsheetLine
+	for _, r := range sheet.Rows {
+		// rows without reference follow the previous one
+		number := len(lines) + 1
+		if len(lines) > 0 {
+			number = lines[len(lines)-1].number + 1
+		}
+		if r.Reference != "" {
+			var err error
+			number, err = strconv.Atoi(r.Reference)
+			if err != nil || number < 1 || len(lines) > 0 && number <= lines[len(lines)-1].number {
+				return nil, fmt.Errorf("invalid row reference '%s'", r.Reference)
+			}
+		}
+
+		var row []string
+		for _, c := range r.Cells {
+			// cells without reference follow the previous one
+			column := len(row)
+			if c.Reference != "" {
+				column = cellColumn(c.Reference)
+				if column < 0 {
+					return nil, fmt.Errorf("invalid cell reference '%s'", c.Reference)
+				}
+			}
+
+			value := c.Value
+			switch c.Type {
+			case "s":
+				index, err := strconv.Atoi(c.Value)
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("invalid shared string reference in cell %s", c.Reference)
+				}
+				value = sharedStrings.Items[index].String()
+			case "inlineStr":
+				value = c.Inline.String()
+			}
+
+			for len(row) <= column {
+				row = append(row, "")
+			}
+			row[column] = value
+		}
+		lines = append(lines, spreadsheetLine{number: number, cells: row})
+	}
+
+	return lines, nil
+}
+
+func decodeZipXml(f *zip.File, v interface{}) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(content, v)
+}
+
+// xlsxMaxColumns is the number of columns a worksheet can have, the last one is XFD.
+const xlsxMaxColumns = 16384
+
+// columnIndex converts a column letter like "A" or "AB" to its 0-based index, -1 if not a column letter or beyond XFD.
+func columnIndex(letters string) int {
+	if letters == "" {
+		return -1
+	}
+	index := 0
+	for _, l := range strings.ToUpper(letters) {
+		if l < 'A' || l > 'Z' {
+			return -1
+		}
+		index = index*26 + int(l-'A'+1)
+		if index > xlsxMaxColumns {
+			return -1
+		}
+	}
+	return index - 1
+}
+
+// cellColumn returns the 0-based column of a cell reference like "B7", -1 if it is not a valid reference.
+func cellColumn(reference string) int {
+	letters := strings.TrimRight(reference, "0123456789")
+	row, err := strconv.Atoi(reference[len(letters):])
+	if err != nil || row < 1 {
+		return -1
+	}
+	return columnIndex(letters)
+}
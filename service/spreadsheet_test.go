@@ -0,0 +1,230 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/swimresults/athlete-service/dto"
+	"reflect"
+	"testing"
+)
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		letters string
+		want    int
+	}{
+		{"A", 0},
+		{"z", 25},
+		{"AA", 26},
+		{"AB", 27},
+		{"XFD", 16383},
+		{"XFE", -1},
+		{"ZZZZZZZZZZZZZZZ", -1},
+		{"", -1},
+		{"A1", -1},
+	}
+
+	for _, test := range tests {
+		if got := columnIndex(test.letters); got != test.want {
+			t.Errorf("columnIndex(%q) = %d, want %d", test.letters, got, test.want)
+		}
+	}
+}
+
+func TestCellColumn(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      int
+	}{
+		{"A1", 0},
+		{"C17", 2},
+		{"XFD1048576", 16383},
+		{"1", -1},
+		{"B", -1},
+		{"B0", -1},
+		{"XFE1", -1},
+		{"", -1},
+	}
+
+	for _, test := range tests {
+		if got := cellColumn(test.reference); got != test.want {
+			t.Errorf("cellColumn(%q) = %d, want %d", test.reference, got, test.want)
+		}
+	}
+}
+
+// testXlsx builds a workbook with the given worksheet content and a shared string "Meier, Simon".
+func testXlsx(t *testing.T, sheetData string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	files := map[string]string{
+		"xl/sharedStrings.xml":     `<sst><si><t>Meier, Simon</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		f, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadXlsx(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheetData string
+		want      []spreadsheetLine
+		wantErr   bool
+	}{
+		{
+			name:      "shared and inline strings",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>SV Test</t></is></c><c r="C1"><v>2010</v></c></row>`,
+			want:      []spreadsheetLine{{number: 1, cells: []string{"Meier, Simon", "SV Test", "2010"}}},
+		},
+		{
+			name:      "empty rows and cells left out",
+			sheetData: `<row r="1"><c r="A1"><v>a</v></c></row><row r="5"><c r="C5"><v>c</v></c></row>`,
+			want:      []spreadsheetLine{{number: 1, cells: []string{"a"}}, {number: 5, cells: []string{"", "", "c"}}},
+		},
+		{
+			name:      "rows and cells without reference",
+			sheetData: `<row><c><v>a</v></c><c><v>b</v></c></row><row r="3"><c r="B3"><v>c</v></c><c><v>d</v></c></row><row><c><v>e</v></c></row>`,
+			want:      []spreadsheetLine{{number: 1, cells: []string{"a", "b"}}, {number: 3, cells: []string{"", "c", "d"}}, {number: 4, cells: []string{"e"}}},
+		},
+		{
+			name:      "cell reference without column",
+			sheetData: `<row r="1"><c r="1"><v>a</v></c></row>`,
+			wantErr:   true,
+		},
+		{
+			name:      "column beyond XFD",
+			sheetData: `<row r="1"><c r="ZZZZZZ1"><v>a</v></c></row>`,
+			wantErr:   true,
+		},
+		{
+			name:      "rows out of order",
+			sheetData: `<row r="2"><c r="A2"><v>a</v></c></row><row r="1"><c r="A1"><v>b</v></c></row>`,
+			wantErr:   true,
+		},
+		{
+			name:      "unknown shared string",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>7</v></c></row>`,
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := readSpreadsheet(testXlsx(t, test.sheetData))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadCsv(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []spreadsheetLine
+	}{
+		{
+			name: "comma",
+			data: "name,year\nMeier,2010\n",
+			want: []spreadsheetLine{{number: 1, cells: []string{"name", "year"}}, {number: 2, cells: []string{"Meier", "2010"}}},
+		},
+		{
+			name: "semicolon with byte order mark",
+			data: "\xef\xbb\xbfname;year\nMeier, Simon;2010\n",
+			want: []spreadsheetLine{{number: 1, cells: []string{"name", "year"}}, {number: 2, cells: []string{"Meier, Simon", "2010"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := readSpreadsheet([]byte(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveColumn(t *testing.T) {
+	header := []string{"Name", "Jahrgang", "Verein"}
+	tests := []struct {
+		column string
+		want   int
+	}{
+		{"", -1},
+		{"B", 1},
+		{"3", 2},
+		{"verein", 2},
+		{" Jahrgang ", 1},
+		{"Geschlecht", -1},
+	}
+
+	for _, test := range tests {
+		if got := resolveColumn(test.column, header); got != test.want {
+			t.Errorf("resolveColumn(%q) = %d, want %d", test.column, got, test.want)
+		}
+	}
+}
+
+func TestParseSpreadsheet(t *testing.T) {
+	data := testXlsx(t, `<row r="1"><c r="A1" t="inlineStr"><is><t>Name</t></is></c><c r="B1" t="inlineStr"><is><t>Jahrgang</t></is></c><c r="C1" t="inlineStr"><is><t>Verein</t></is></c></row>`+
+		`<row r="4"><c r="A4" t="s"><v>0</v></c><c r="B4"><v>2010</v></c><c r="C4" t="inlineStr"><is><t>SV Test</t></is></c></row>`+
+		`<row r="5"><c r="A5"><v></v></c></row>`+
+		`<row r="9"><c r="A9" t="inlineStr"><is><t>Schulze, Anna</t></is></c><c r="B9" t="inlineStr"><is><t>zwölf</t></is></c><c r="C9" t="inlineStr"><is><t>SV Test</t></is></c></row>`)
+
+	rows, err := parseSpreadsheet(data, dto.SpreadsheetColumnMappingDto{Name: "Name", Year: "Jahrgang", Club: "Verein", Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		row     int
+		name    string
+		skipped bool
+		err     string
+	}{
+		{row: 4, name: "Meier, Simon"},
+		{row: 5, skipped: true},
+		{row: 9, err: "invalid year 'zwölf'"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		got := rows[i]
+		if got.row != w.row || got.athlete.Name != w.name || got.skipped != w.skipped || got.err != w.err {
+			t.Errorf("row %d: got %+v, want %+v", i, got, w)
+		}
+	}
+
+	if _, err := parseSpreadsheet(data, dto.SpreadsheetColumnMappingDto{Name: "Name", Club: "Verein", Header: true}); err == nil {
+		t.Error("expected error for mapping without year column")
+	}
+}
@@ -4,29 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/dto"
 	"github.com/swimresults/athlete-service/model"
 	"github.com/swimresults/service-core/misc"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
 	"time"
 )
 
 var teamCollection *mongo.Collection
+var teamLogFields log.Fields
 
 func teamService(database *mongo.Database) {
 	teamCollection = database.Collection("team")
+	teamLogFields = log.Fields{"sr_service": "team"}
+
+	ensureUniqueDsvIdIndex(teamCollection)
+	ensureIndexes(teamCollection,
+		mongo.IndexModel{Keys: bson.D{{"participation", 1}}},
+		mongo.IndexModel{Keys: bson.D{{"participation", 1}, {"name", 1}}, Options: options.Index().SetCollation(nameCollation)},
+		mongo.IndexModel{Keys: bson.D{{"name", 1}}, Options: options.Index().SetCollation(nameCollation)},
+		mongo.IndexModel{Keys: bson.D{{"country", 1}}},
+		mongo.IndexModel{Keys: bson.D{{"state_id", 1}}},
+	)
 }
 
-func getTeamsByBsonDocument(d interface{}) ([]model.Team, error) {
-	return getTeamsByBsonDocumentWithOptions(d, options.FindOptions{})
+// teamSortFields are the fields team lists can be sorted by.
+var teamSortFields = map[string][]string{
+	"name":          {"name"},
+	"country":       {"country"},
+	"state_id":      {"state_id"},
+	"dsv_id":        {"dsv_id"},
+	"first_meeting": {"first_meeting"},
 }
 
-func getTeamsByBsonDocumentWithOptions(d interface{}, fOps options.FindOptions) ([]model.Team, error) {
+func getTeamsByBsonDocument(ctx context.Context, d interface{}) ([]model.Team, error) {
+	return getTeamsByBsonDocumentWithOptions(ctx, d, options.FindOptions{})
+}
+
+func getTeamsByBsonDocumentWithOptions(ctx context.Context, d interface{}, fOps options.FindOptions) ([]model.Team, error) {
 	var teams []model.Team
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	fOps.SetSort(bson.D{{"name", 1}})
@@ -51,15 +74,39 @@ func getTeamsByBsonDocumentWithOptions(d interface{}, fOps options.FindOptions)
 	return teams, nil
 }
 
-func GetTeams(paging Paging) ([]model.Team, error) {
-	return getTeamsByBsonDocumentWithOptions(
+// getTeamPage returns a page of the teams matching the filter, ordered by the sort of the paging or by name, see
+// findPage.
+func getTeamPage(ctx context.Context, d interface{}, paging Paging) ([]model.Team, Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, paging.timeout())
+	defer cancel()
+
+	sort, err := paging.sortDocument(teamSortFields, bson.D{{"name", 1}})
+	if err != nil {
+		return []model.Team{}, Page{}, err
+	}
+
+	opts, err := teamFindOptions(paging.Fields, sort)
+	if err != nil {
+		return []model.Team{}, Page{}, err
+	}
+
+	teams, page, err := findPage[model.Team](ctx, teamCollection, d, sort, opts, paging, nil)
+	if err != nil {
+		return []model.Team{}, Page{}, err
+	}
+
+	return teams, page, nil
+}
+
+func GetTeams(paging Paging) ([]model.Team, Page, error) {
+	return getTeamPage(context.Background(),
 		bson.M{
 			"$or": []interface{}{
 				bson.M{"name": bson.M{"$regex": paging.Query, "$options": "i"}},
 				bson.M{"alias": bson.M{"$regex": paging.Query, "$options": "i"}},
 				bson.M{"alias": bson.M{"$regex": misc.Aliasify(paging.Query), "$options": "i"}},
 			},
-		}, paging.getPaginatedOpts())
+		}, paging)
 }
 
 func GetTeamsAmount() (int, error) {
@@ -86,8 +133,8 @@ func GetTeamsAmountByMeeting(meeting string) (int, error) {
 	return int(count), nil
 }
 
-func GetTeamsByMeeting(id string, paging Paging) ([]model.Team, error) {
-	return getTeamsByBsonDocumentWithOptions(bson.M{
+func GetTeamsByMeeting(id string, paging Paging) ([]model.Team, Page, error) {
+	return getTeamPage(context.Background(), bson.M{
 		"$and": []interface{}{
 			bson.M{"participation": id},
 			bson.M{
@@ -98,11 +145,24 @@ func GetTeamsByMeeting(id string, paging Paging) ([]model.Team, error) {
 				},
 			},
 		},
-	}, paging.getPaginatedOpts())
+	}, paging)
+}
+
+// TeamExists reports whether a team with the given id exists without reading it.
+func TeamExists(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := teamCollection.CountDocuments(ctx, bson.D{{"_id", id}}, options.Count().SetLimit(1))
+	return count > 0, err
 }
 
 func GetTeamById(id primitive.ObjectID) (model.Team, error) {
-	teams, err := getTeamsByBsonDocument(bson.D{{"_id", id}})
+	return getTeamById(context.Background(), id)
+}
+
+func getTeamById(ctx context.Context, id primitive.ObjectID) (model.Team, error) {
+	teams, err := getTeamsByBsonDocument(ctx, bson.D{{"_id", id}})
 	if err != nil {
 		return model.Team{}, err
 	}
@@ -113,8 +173,50 @@ func GetTeamById(id primitive.ObjectID) (model.Team, error) {
 	return teams[0], nil
 }
 
+// teamFindOptions returns the options of team lists projected to the given fields, the sort keys are always kept for
+// the cursor of the next page.
+func teamFindOptions(fields Fields, sort bson.D) (*options.FindOptions, error) {
+	if err := fields.validate(teamFields); err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetCollation(nameCollation)
+	if projection := fields.projection(nil, sortKeys(sort)...); projection != nil {
+		opts.SetProjection(projection)
+	}
+	return opts, nil
+}
+
+// GetTeamWithFieldsById returns the team with only the selected fields, see Fields.
+func GetTeamWithFieldsById(id primitive.ObjectID, fields Fields) (model.Team, error) {
+	if err := fields.validate(teamFields); err != nil {
+		return model.Team{}, err
+	}
+	return getTeamWithFieldsById(context.Background(), id, fields)
+}
+
+func getTeamWithFieldsById(ctx context.Context, id primitive.ObjectID, fields Fields) (model.Team, error) {
+	if len(fields) == 0 {
+		return getTeamById(ctx, id)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var team model.Team
+	err := teamCollection.FindOne(ctx, bson.D{{"_id", id}}, options.FindOne().SetProjection(fields.projection(nil))).Decode(&team)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Team{}, errors.New("no entry with given id found")
+	}
+	return team, err
+}
+
 func GetTeamByDsvId(dsvId int) (model.Team, error) {
-	teams, err := getTeamsByBsonDocument(bson.D{{"dsv_id", dsvId}})
+	return getTeamByDsvId(context.Background(), dsvId)
+}
+
+func getTeamByDsvId(ctx context.Context, dsvId int) (model.Team, error) {
+	teams, err := getTeamsByBsonDocument(ctx, bson.D{{"dsv_id", dsvId}})
 	if err != nil {
 		return model.Team{}, err
 	}
@@ -126,7 +228,11 @@ func GetTeamByDsvId(dsvId int) (model.Team, error) {
 }
 
 func GetTeamByName(name string) (model.Team, error) {
-	teams, err := getTeamsByBsonDocument(
+	return getTeamByName(context.Background(), name)
+}
+
+func getTeamByName(ctx context.Context, name string) (model.Team, error) {
+	teams, err := getTeamsByBsonDocument(ctx,
 		bson.M{
 			"$or": []interface{}{
 				bson.M{"name": bson.M{"$regex": name, "$options": "i"}},
@@ -145,7 +251,7 @@ func GetTeamByName(name string) (model.Team, error) {
 }
 
 func GetTeamByAlias(alias string) (model.Team, error) {
-	teams, err := getTeamsByBsonDocument(
+	teams, err := getTeamsByBsonDocument(context.Background(),
 		bson.M{
 			"$or": []interface{}{
 				bson.M{"name": bson.M{"$regex": alias, "$options": "i"}},
@@ -162,8 +268,8 @@ func GetTeamByAlias(alias string) (model.Team, error) {
 	return teams[0], nil
 }
 
-func AddTeam(team model.Team) (model.Team, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func AddTeam(ctx context.Context, team model.Team) (model.Team, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	team.Alias = append(team.Alias, misc.Aliasify(team.Name))
@@ -172,20 +278,29 @@ func AddTeam(team model.Team) (model.Team, error) {
 	if err != nil {
 		return model.Team{}, err
 	}
+	team.Identifier = r.InsertedID.(primitive.ObjectID)
+
+	err = recordChange(ctx, model.AuditActionAdd, "team", team.Identifier, nil, team)
+	if err != nil {
+		return model.Team{}, err
+	}
 
-	return GetTeamById(r.InsertedID.(primitive.ObjectID))
+	return getTeamById(ctx, team.Identifier)
 }
 
-func AddTeamParticipation(id primitive.ObjectID, meetId string) (model.Team, error) {
+func AddTeamParticipation(ctx context.Context, id primitive.ObjectID, meetId string) (model.Team, error) {
 	fmt.Printf("add participation to team: %s (%s)\n", id.String(), meetId)
-	team, err := GetTeamById(id)
+	ctx = withProvenanceMeeting(ctx, meetId)
+
+	team, err := getTeamById(ctx, id)
 	if err != nil {
 		return model.Team{}, err
 	}
+	before := team
 
 	team.Participation = misc.AppendWithoutDuplicates(team.Participation, meetId)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	_, err = teamCollection.ReplaceOne(ctx, bson.D{{"_id", team.Identifier}}, team)
@@ -193,74 +308,259 @@ func AddTeamParticipation(id primitive.ObjectID, meetId string) (model.Team, err
 		return model.Team{}, err
 	}
 
-	return GetTeamById(team.Identifier)
-}
+	err = recordChange(ctx, model.AuditActionParticipation, "team", team.Identifier, before, team)
+	if err != nil {
+		return model.Team{}, err
+	}
 
-func ImportTeam(team model.Team, meetId string) (model.Team, bool, error) {
-	existingTeam, err := GetTeamByName(team.Name)
+	return getTeamById(ctx, team.Identifier)
+}
 
+// ImportTeam runs in a transaction if the database supports it. If the context carries an import batch the change is
+// recorded for a later rollback. Concurrent imports of the same team are serialized by an import lock on its name,
+// which is also held in the database, so imports on different instances cannot both create the same team. The unique
+// dsv_id index and a retry additionally match a team created with the same dsv_id by a write that did not lock.
+func ImportTeam(ctx context.Context, team model.Team, meetId string) (model.Team, bool, error) {
+	unlock, err := lockImport("team:name:" + misc.Aliasify(team.Name))
 	if err != nil {
-		if err.Error() == "no team with given name found" {
+		return model.Team{}, false, err
+	}
+	defer unlock()
+
+	ctx = withAuditAction(withProvenanceMeeting(ctx, meetId), model.AuditActionImport)
+
+	existingTeam, created, err := importTeam(ctx, team, meetId)
+	if mongo.IsDuplicateKeyError(err) {
+		existingTeam, created, err = importTeam(ctx, team, meetId)
+	}
+	return existingTeam, created, err
+}
+
+func importTeam(ctx context.Context, team model.Team, meetId string) (model.Team, bool, error) {
+	var existingTeam model.Team
+	var created bool
+
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		plan, err := planTeamImport(ctx, team, meetId)
+		if err != nil {
+			return err
+		}
+		created = !plan.found
 
+		if !plan.found {
 			fmt.Printf("import of team '%s', not existing so far\n", team.Name)
-			team.FirstMeeting = meetId
-			newTeam, err2 := AddTeam(team)
-			if err2 != nil {
-				return model.Team{}, false, err2
+			existingTeam, err = AddTeam(ctx, plan.team)
+			if err != nil {
+				return err
 			}
 
-			newTeam, err2 = AddTeamParticipation(newTeam.Identifier, meetId)
-			if err2 != nil {
-				return model.Team{}, false, err2
+			err = recordImportBatchChange(ctx, teamCollection, "team", existingTeam.Identifier, true)
+			if err != nil {
+				return err
 			}
 
-			return newTeam, true, nil
+			existingTeam, err = AddTeamParticipation(ctx, existingTeam.Identifier, meetId)
+			return err
+		}
+
+		existingTeam = plan.team
+
+		fmt.Printf("import of team '%s', already present\n", team.Name)
+
+		err = recordImportBatchChange(ctx, teamCollection, "team", existingTeam.Identifier, false)
+		if err != nil {
+			return err
+		}
+
+		err = recordImportConflicts(ctx, "team", existingTeam.Identifier, plan.conflicts)
+		if err != nil {
+			return err
 		}
+
+		if len(plan.changes) > 0 {
+			fmt.Printf("updating some values...\n")
+			existingTeam, err = UpdateTeam(ctx, existingTeam)
+			if err != nil {
+				return err
+			}
+		}
+
+		existingTeam, err = AddTeamParticipation(ctx, existingTeam.Identifier, meetId)
+		return err
+	})
+	if err != nil {
 		return model.Team{}, false, err
 	}
 
-	changed := false
-	if existingTeam.DsvId == 0 && team.DsvId != 0 {
+	return existingTeam, created, nil
+}
+
+// PlanTeamImport runs the matching of ImportTeam and returns the planned changes without writing anything.
+func PlanTeamImport(team model.Team, meetId string) (dto.ImportPlanDto, error) {
+	plan, err := planTeamImport(context.Background(), team, meetId)
+	if err != nil {
+		return dto.ImportPlanDto{}, err
+	}
+	return plan.dto(), nil
+}
+
+type teamImportPlan struct {
+	team          model.Team
+	found         bool
+	changes       []dto.FieldChangeDto
+	conflicts     []dto.FieldChangeDto
+	participation bool
+	warnings      []string
+}
+
+func (p teamImportPlan) dto() dto.ImportPlanDto {
+	plan := dto.ImportPlanDto{
+		Action:        dto.ImportActionCreate,
+		Changes:       p.changes,
+		Conflicts:     p.conflicts,
+		Participation: p.participation,
+		Warnings:      p.warnings,
+		Team:          &p.team,
+	}
+	if p.found {
+		plan.Action = dto.ImportActionMatch
+		plan.MatchedBy = "name"
+	}
+	return plan
+}
+
+// planTeamImport searches the imported team by name and applies the values an import would fill in to the existing
+// team. Locked fields are never changed, differing values for them are listed as conflicts. Matches that might be wrong
+// are listed as warnings.
+func planTeamImport(ctx context.Context, team model.Team, meetId string) (teamImportPlan, error) {
+	existingTeam, err := getTeamByName(ctx, team.Name)
+	if err != nil {
+		if err.Error() == "no team with given name found" {
+			team.FirstMeeting = meetId
+			return teamImportPlan{team: team, participation: true}, nil
+		}
+		return teamImportPlan{}, err
+	}
+
+	plan := teamImportPlan{found: true, participation: true}
+	change := func(field string, from interface{}, to interface{}) {
+		plan.changes = append(plan.changes, dto.FieldChangeDto{Field: field, From: from, To: to})
+	}
+
+	plan.conflicts, err = lockConflicts(existingTeam, team, existingTeam.Locked)
+	if err != nil {
+		return teamImportPlan{}, err
+	}
+
+	if existingTeam.DsvId == 0 && team.DsvId != 0 && !isLocked(existingTeam.Locked, "dsv_id") {
+		change("dsv_id", existingTeam.DsvId, team.DsvId)
 		existingTeam.DsvId = team.DsvId
-		changed = true
 	}
-	if existingTeam.StateId == 0 && team.StateId != 0 {
+	if existingTeam.StateId == 0 && team.StateId != 0 && !isLocked(existingTeam.Locked, "state_id") {
+		change("state_id", existingTeam.StateId, team.StateId)
 		existingTeam.StateId = team.StateId
-		changed = true
 	}
-	if existingTeam.Country == "" && team.Country != "" {
+	if existingTeam.Country == "" && team.Country != "" && !isLocked(existingTeam.Locked, "country") {
+		change("country", existingTeam.Country, team.Country)
 		existingTeam.Country = team.Country
-		changed = true
 	}
 
-	fmt.Printf("import of team '%s', already present\n", team.Name)
+	if !strings.EqualFold(strings.TrimSpace(team.Name), existingTeam.Name) {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("name '%s' only partially matches '%s'", team.Name, existingTeam.Name))
+	}
+	if team.DsvId != 0 && existingTeam.DsvId != team.DsvId {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("dsv_id %d differs from existing dsv_id %d", team.DsvId, existingTeam.DsvId))
+	}
+	if team.Country != "" && existingTeam.Country != team.Country {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("country '%s' differs from existing country '%s'", team.Country, existingTeam.Country))
+	}
+
+	for _, p := range existingTeam.Participation {
+		if p == meetId {
+			plan.participation = false
+		}
+	}
 
-	if changed {
-		fmt.Printf("updating some values...\n")
-		existingTeam, err = UpdateTeam(existingTeam)
+	plan.team = existingTeam
+	return plan, nil
+}
+
+// ImportTeams imports every given team on its own, a failing team does not stop the remaining ones.
+// On a dry run only the plan of every team is returned.
+func ImportTeams(ctx context.Context, requests []dto.ImportTeamRequestDto, dryRun bool) []dto.ImportTeamResultDto {
+	results := make([]dto.ImportTeamResultDto, len(requests))
+
+	for i, request := range requests {
+		results[i].Index = i
+
+		if request.Meeting == "" {
+			results[i].Status = dto.ImportStatusFailed
+			results[i].Error = "given meeting is empty"
+			continue
+		}
+
+		var team model.Team
+		var created bool
+		var err error
+		if dryRun {
+			var plan dto.ImportPlanDto
+			plan, err = PlanTeamImport(request.Team, request.Meeting)
+			results[i].Plan = &plan
+			if plan.Team != nil {
+				team = *plan.Team
+			}
+			created = plan.Action == dto.ImportActionCreate
+		} else {
+			team, created, err = ImportTeam(importSourceContext(ctx, request.Source), request.Team, request.Meeting)
+		}
 		if err != nil {
-			return model.Team{}, false, err
+			results[i].Status = dto.ImportStatusFailed
+			results[i].Error = err.Error()
+			results[i].Plan = nil
+			continue
+		}
+
+		results[i].Team = &team
+		if created {
+			results[i].Status = dto.ImportStatusCreated
+		} else {
+			results[i].Status = dto.ImportStatusMatched
 		}
 	}
 
-	existingTeam, err = AddTeamParticipation(existingTeam.Identifier, meetId)
-	if err != nil {
-		return model.Team{}, false, err
+	var statuses []string
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
 	}
+	log.WithFields(teamLogFields).WithFields(importSummaryFields(dryRun, statuses)).Info("teams imported")
 
-	return existingTeam, false, nil
+	return results
 }
 
-func UpdateTeam(team model.Team) (model.Team, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func UpdateTeam(ctx context.Context, team model.Team) (model.Team, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	team.Alias = misc.AppendWithoutDuplicates(team.Alias, misc.Aliasify(team.Name))
 
-	_, err := teamCollection.ReplaceOne(ctx, bson.D{{"_id", team.Identifier}}, team)
+	var before model.Team
+	err := findDocumentById(ctx, teamCollection, team.Identifier, &before)
+	if err != nil {
+		return model.Team{}, err
+	}
+	if team.Locked == nil {
+		team.Locked = before.Locked
+	}
+
+	_, err = teamCollection.ReplaceOne(ctx, bson.D{{"_id", team.Identifier}}, team)
+	if err != nil {
+		return model.Team{}, err
+	}
+
+	err = recordChange(ctx, model.AuditActionUpdate, "team", team.Identifier, before, team)
 	if err != nil {
 		return model.Team{}, err
 	}
 
-	return GetTeamById(team.Identifier)
+	return getTeamById(ctx, team.Identifier)
 }
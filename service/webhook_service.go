@@ -0,0 +1,375 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/swimresults/athlete-service/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var webhookCollection *mongo.Collection
+var webhookDeliveryCollection *mongo.Collection
+var webhookLogFields = log.Fields{"sr_service": "webhook"}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+var webhookTrigger = make(chan struct{}, 1)
+
+const webhookMaxAttempts = 10
+const webhookWorkers = 2
+
+// finished deliveries are kept for a month
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// ErrWebhookDeleted is returned when a delivery of a deleted webhook is replayed.
+var ErrWebhookDeleted = errors.New("webhook of the delivery was deleted")
+
+func webhookService(database *mongo.Database) {
+	webhookCollection = database.Collection("webhook")
+	webhookDeliveryCollection = database.Collection("webhook_delivery", documentMOptions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = webhookDeliveryCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"status", 1}, {"next_attempt_at", 1}}},
+		{Keys: bson.D{{"webhook_id", 1}, {"created_at", -1}}},
+		{Keys: bson.D{{"delivered_at", 1}}, Options: options.Index().SetExpireAfterSeconds(int32(webhookDeliveryRetention.Seconds()))},
+	})
+
+	for i := 0; i < webhookWorkers; i++ {
+		go runWebhookDeliveries()
+	}
+}
+
+func AddWebhook(webhook model.Webhook) (model.Webhook, error) {
+	u, err := url.Parse(webhook.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return model.Webhook{}, errors.New("given url is not a valid http url")
+	}
+	if len(webhook.Events) == 0 {
+		return model.Webhook{}, errors.New("no events given")
+	}
+	for _, event := range webhook.Events {
+		if !isEventFilter(event) {
+			return model.Webhook{}, fmt.Errorf("unknown event '%s'", event)
+		}
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return model.Webhook{}, err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	webhook.Identifier = primitive.NilObjectID
+	webhook.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	r, err := webhookCollection.InsertOne(ctx, webhook)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+	webhook.Identifier = r.InsertedID.(primitive.ObjectID)
+
+	fields := log.Fields{"webhook_id": webhook.Identifier, "url": webhook.Url, "events": webhook.Events}
+	log.WithFields(webhookLogFields).WithFields(fields).Info("webhook added")
+
+	// the secret is only returned once
+	return webhook, nil
+}
+
+func isEventFilter(filter string) bool {
+	for _, event := range model.EventTypes {
+		if filter == "*" || filter == event || filter == strings.SplitN(event, ".", 2)[0]+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+func GetWebhooks(paging Paging) ([]model.Webhook, Page, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), paging.timeout())
+	defer cancel()
+
+	webhooks, page, err := findPage(ctx, webhookCollection, bson.D{}, bson.D{{"_id", 1}}, nil, paging, func(webhook model.Webhook) model.Webhook {
+		webhook.Secret = ""
+		return webhook
+	})
+	if err != nil {
+		return []model.Webhook{}, Page{}, err
+	}
+	return webhooks, page, nil
+}
+
+func GetWebhookById(id primitive.ObjectID) (model.Webhook, error) {
+	webhook, err := getWebhookById(context.Background(), id)
+	webhook.Secret = ""
+	return webhook, err
+}
+
+func getWebhookById(ctx context.Context, id primitive.ObjectID) (model.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var webhook model.Webhook
+	err := findDocumentById(ctx, webhookCollection, id, &webhook)
+	return webhook, err
+}
+
+// RemoveWebhookById deletes the webhook, its pending deliveries are dropped.
+func RemoveWebhookById(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := webhookCollection.DeleteOne(ctx, bson.D{{"_id", id}})
+	if err != nil {
+		return err
+	}
+
+	_, err = webhookDeliveryCollection.DeleteMany(ctx, bson.D{{"webhook_id", id}, {"status", model.WebhookDeliveryPending}})
+	if err != nil {
+		return err
+	}
+
+	fields := log.Fields{"webhook_id": id}
+	log.WithFields(webhookLogFields).WithFields(fields).Info("webhook deleted")
+	return nil
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook newest first, optionally filtered by status.
+func GetWebhookDeliveries(id primitive.ObjectID, status string, paging Paging) ([]model.WebhookDelivery, Page, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), paging.timeout())
+	defer cancel()
+
+	filter := bson.D{{"webhook_id", id}}
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+
+	deliveries, page, err := findPage[model.WebhookDelivery](ctx, webhookDeliveryCollection, filter, bson.D{{"created_at", -1}, {"_id", -1}}, nil, paging, nil)
+	if err != nil {
+		return []model.WebhookDelivery{}, Page{}, err
+	}
+	return deliveries, page, nil
+}
+
+// ReplayWebhookDelivery sends the event of the given delivery again as a new delivery. Deliveries of a deleted webhook
+// cannot be replayed.
+func ReplayWebhookDelivery(id primitive.ObjectID) (model.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var delivery model.WebhookDelivery
+	err := findDocumentById(ctx, webhookDeliveryCollection, id, &delivery)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	if _, err := getWebhookById(ctx, delivery.WebhookId); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return model.WebhookDelivery{}, ErrWebhookDeleted
+		}
+		return model.WebhookDelivery{}, err
+	}
+
+	replay := model.WebhookDelivery{
+		WebhookId:     delivery.WebhookId,
+		Event:         delivery.Event,
+		Status:        model.WebhookDeliveryPending,
+		ReplayOf:      delivery.Identifier,
+		NextAttemptAt: time.Now(),
+		CreatedAt:     time.Now(),
+	}
+
+	r, err := webhookDeliveryCollection.InsertOne(ctx, replay)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	replay.Identifier = r.InsertedID.(primitive.ObjectID)
+
+	triggerWebhookDeliveries()
+	return replay, nil
+}
+
+// queueWebhookDeliveries adds a pending delivery for every webhook subscribed to the event.
+func queueWebhookDeliveries(ctx context.Context, event model.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	meetings := event.Meetings
+	if meetings == nil {
+		meetings = []string{}
+	}
+
+	filter := bson.D{
+		{"events", bson.D{{"$in", bson.A{event.Type, strings.SplitN(event.Type, ".", 2)[0] + ".*", "*"}}}},
+		{"$or", bson.A{
+			bson.D{{"meeting", bson.D{{"$exists", false}}}},
+			bson.D{{"meeting", bson.D{{"$in", meetings}}}},
+		}},
+	}
+
+	cursor, err := webhookCollection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	var webhooks []model.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	var deliveries []interface{}
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookId:     webhook.Identifier,
+			Event:         event,
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+			CreatedAt:     time.Now(),
+		})
+	}
+
+	_, err = webhookDeliveryCollection.InsertMany(ctx, deliveries)
+	if err != nil {
+		return err
+	}
+
+	triggerWebhookDeliveries()
+	return nil
+}
+
+func triggerWebhookDeliveries() {
+	select {
+	case webhookTrigger <- struct{}{}:
+	default:
+	}
+}
+
+func runWebhookDeliveries() {
+	for {
+		for deliverNextWebhook() {
+		}
+
+		select {
+		case <-webhookTrigger:
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// deliverNextWebhook claims the next due delivery and sends it, it reports whether there was one. A claimed delivery
+// is locked for a minute, so another instance does not send it at the same time.
+func deliverNextWebhook() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var delivery model.WebhookDelivery
+	err := webhookDeliveryCollection.FindOneAndUpdate(ctx,
+		bson.D{{"status", model.WebhookDeliveryPending}, {"next_attempt_at", bson.D{{"$lte", now}}}},
+		bson.D{{"$set", bson.D{{"next_attempt_at", now.Add(time.Minute)}}}},
+		options.FindOneAndUpdate().SetSort(bson.D{{"next_attempt_at", 1}}).SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.WithFields(webhookLogFields).WithFields(log.Fields{"error": err.Error()}).Error("unable to claim webhook delivery")
+		}
+		return false
+	}
+
+	status, err := sendWebhook(context.Background(), delivery)
+
+	// a deleted webhook is not retried, its delivery fails right away
+	delivery.Attempts++
+	update := bson.D{{"attempts", delivery.Attempts}, {"response_status", status}}
+	if err == nil {
+		update = append(update, bson.E{Key: "status", Value: model.WebhookDeliveryDelivered}, bson.E{Key: "delivered_at", Value: time.Now()}, bson.E{Key: "error", Value: ""})
+	} else if delivery.Attempts >= webhookMaxAttempts || errors.Is(err, ErrWebhookDeleted) {
+		update = append(update, bson.E{Key: "status", Value: model.WebhookDeliveryFailed}, bson.E{Key: "delivered_at", Value: time.Now()}, bson.E{Key: "error", Value: err.Error()})
+	} else {
+		update = append(update, bson.E{Key: "next_attempt_at", Value: time.Now().Add(retryBackoff(delivery.Attempts))}, bson.E{Key: "error", Value: err.Error()})
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, updateErr := webhookDeliveryCollection.UpdateOne(ctx, bson.D{{"_id", delivery.Identifier}}, bson.D{{"$set", update}})
+	if updateErr != nil {
+		log.WithFields(webhookLogFields).WithFields(log.Fields{"delivery_id": delivery.Identifier, "error": updateErr.Error()}).Error("unable to store webhook delivery")
+	}
+
+	fields := log.Fields{"delivery_id": delivery.Identifier, "webhook_id": delivery.WebhookId, "event": delivery.Event.Type, "attempts": delivery.Attempts}
+	if err != nil {
+		log.WithFields(webhookLogFields).WithFields(fields).WithFields(log.Fields{"error": err.Error()}).Warn("webhook delivery failed")
+	} else {
+		log.WithFields(webhookLogFields).WithFields(fields).Info("webhook delivered")
+	}
+	return true
+}
+
+// sendWebhook posts the event as JSON together with its webhookSignature in the X-Webhook-Signature header.
+// ErrWebhookDeleted is returned if the webhook does not exist anymore.
+func sendWebhook(ctx context.Context, delivery model.WebhookDelivery) (int, error) {
+	webhook, err := getWebhookById(ctx, delivery.WebhookId)
+	if errors.Is(err, ErrNotFound) {
+		return 0, ErrWebhookDeleted
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Event", delivery.Event.Type)
+	request.Header.Set("X-Webhook-Delivery", delivery.Identifier.Hex())
+	request.Header.Set("X-Webhook-Signature", webhookSignature(webhook.Secret, body))
+
+	response, err := webhookClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// webhookSignature returns the hex encoded HMAC-SHA256 of the body keyed with the secret of the webhook, prefixed by
+// the algorithm.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsEventFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"*", true},
+		{"athlete.created", true},
+		{"participation.removed", true},
+		{"athlete.*", true},
+		{"certificate.*", true},
+		{"athlete.merged", false},
+		{"meeting.*", false},
+		{"athlete", false},
+		{"athlete.", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := isEventFilter(test.filter); got != test.want {
+			t.Errorf("isEventFilter(%q) = %t, want %t", test.filter, got, test.want)
+		}
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	tests := []struct {
+		secret string
+		body   string
+		want   string
+	}{
+		{"key", "The quick brown fox jumps over the lazy dog", "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"", "", "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+	}
+
+	for _, test := range tests {
+		if got := webhookSignature(test.secret, []byte(test.body)); got != test.want {
+			t.Errorf("webhookSignature(%q, %q) = %s, want %s", test.secret, test.body, got, test.want)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 10 * time.Second},
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{5, 160 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{1000, time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := retryBackoff(test.attempts); got != test.want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}